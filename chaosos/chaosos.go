@@ -0,0 +1,258 @@
+// Package chaosos provides an OS abstraction implementation that randomly
+// injects faults into calls to another implementation.
+//
+// Faults include realistic errors (wrapped like the errors of the os package),
+// short reads, and partial writes. All randomness derives from a seed, so a
+// sequence of calls that failed with a given seed fails the same way when it
+// is repeated with that seed:
+//
+//	c := chaosos.New(vos.New(), seed, chaosos.WithRate(0.2))
+//	defer func() {
+//		if t.Failed() {
+//			t.Logf("seed %d, faults: %v", c.Seed(), c.Faults())
+//		}
+//	}()
+//
+// Note that concurrent calls may be interleaved in a different order between
+// runs, which breaks determinism.
+package chaosos
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/faultos"
+	"github.com/echocrow/osa/hookos"
+)
+
+// Kind describes the kind of an injected fault.
+type Kind string
+
+// Kinds of injected faults.
+const (
+	KindError        Kind = "error"
+	KindShortRead    Kind = "short read"
+	KindPartialWrite Kind = "partial write"
+)
+
+// Fault describes an injected fault.
+type Fault struct {
+	// Call is the number of the faulted call among all calls that may be
+	// faulted, starting at 1.
+	Call int
+	// Op is the name of the faulted method, e.g. "WriteFile" or "File.Write".
+	Op string
+	// Paths holds the file paths of the faulted call, if any.
+	Paths []string
+	// Kind is the kind of the fault.
+	Kind Kind
+	// N is the number of bytes short reads and partial writes are shortened
+	// to, i.e. the length of the buffer passed on.
+	N int
+	// Requested is the number of bytes the caller requested from short reads
+	// and partial writes, i.e. the length of its buffer.
+	Requested int
+	// Err is the injected error. Short reads do not inject errors.
+	Err error
+}
+
+func (f Fault) String() string {
+	s := fmt.Sprintf("call %d: %s", f.Call, f.Op)
+	if len(f.Paths) > 0 {
+		s += " " + strings.Join(f.Paths, " ")
+	}
+	switch f.Kind {
+	case KindShortRead, KindPartialWrite:
+		s += fmt.Sprintf(": %s of %d of %d bytes", f.Kind, f.N, f.Requested)
+	}
+	if f.Err != nil {
+		s += ": " + f.Err.Error()
+	}
+	return s
+}
+
+// Option configures a chaosos instance.
+type Option func(c *config)
+
+type config struct {
+	rate    float64
+	opRates map[string]float64
+	log     io.Writer
+}
+
+// WithRate sets the probability of faulting any call. Defaults to 0.1.
+func WithRate(p float64) Option {
+	return func(c *config) {
+		c.rate = p
+	}
+}
+
+// WithOpRate sets the probability of faulting calls of method op, e.g.
+// "WriteFile" or "File.Read", overriding the default rate.
+func WithOpRate(op string, p float64) Option {
+	return func(c *config) {
+		c.opRates[op] = p
+	}
+}
+
+// WithLog writes each injected fault to w, one per line.
+func WithLog(w io.Writer) Option {
+	return func(c *config) {
+		c.log = w
+	}
+}
+
+type chaosos struct {
+	osa.I
+	ch *chaos
+}
+
+// New creates a new chaosos instance that wraps o and randomly faults calls,
+// deriving all randomness from seed.
+//
+// Methods that do not return an error, such as Getenv, are never faulted.
+func New(o osa.I, seed int64, opts ...Option) chaosos {
+	c := config{
+		rate:    0.1,
+		opRates: make(map[string]float64),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	ch := &chaos{
+		config: c,
+		seed:   seed,
+		rand:   rand.New(rand.NewSource(seed)),
+	}
+	return chaosos{hookos.New(o, ch.hook), ch}
+}
+
+// Seed returns the seed of the chaosos instance.
+func (c chaosos) Seed() int64 {
+	return c.ch.seed
+}
+
+// Faults returns all faults injected so far.
+func (c chaosos) Faults() []Fault {
+	c.ch.mu.Lock()
+	defer c.ch.mu.Unlock()
+	faults := make([]Fault, len(c.ch.faults))
+	copy(faults, c.ch.faults)
+	return faults
+}
+
+// opErrs lists realistic errors per method. Other methods fail with EIO.
+var opErrs = map[string][]syscall.Errno{
+	"Open":          {syscall.EACCES, syscall.EMFILE, syscall.EIO},
+	"OpenFile":      {syscall.EACCES, syscall.EMFILE, syscall.ENOSPC, syscall.EIO},
+	"Create":        {syscall.EACCES, syscall.EMFILE, syscall.ENOSPC, syscall.EIO},
+	"ReadDir":       {syscall.EACCES, syscall.EMFILE, syscall.EIO},
+	"ReadFile":      {syscall.EACCES, syscall.EMFILE, syscall.EIO},
+	"WriteFile":     {syscall.EACCES, syscall.ENOSPC, syscall.EDQUOT, syscall.EIO},
+	"Mkdir":         {syscall.EACCES, syscall.ENOSPC, syscall.EIO},
+	"MkdirAll":      {syscall.EACCES, syscall.ENOSPC, syscall.EIO},
+	"MkdirTemp":     {syscall.EACCES, syscall.ENOSPC, syscall.EIO},
+	"Rename":        {syscall.EACCES, syscall.EXDEV, syscall.EBUSY, syscall.EIO},
+	"Remove":        {syscall.EACCES, syscall.EBUSY, syscall.EIO},
+	"RemoveAll":     {syscall.EACCES, syscall.EBUSY, syscall.EIO},
+	"Link":          {syscall.EACCES, syscall.EXDEV, syscall.EMLINK},
+	"Symlink":       {syscall.EACCES, syscall.ENOSPC, syscall.EIO},
+	"Stat":          {syscall.EACCES, syscall.EIO},
+	"Lstat":         {syscall.EACCES, syscall.EIO},
+	"Chmod":         {syscall.EPERM, syscall.EIO},
+	"Chtimes":       {syscall.EPERM, syscall.EIO},
+	"Chdir":         {syscall.EACCES, syscall.EIO},
+	"Setenv":        {syscall.ENOMEM},
+	"File.Write":    {syscall.ENOSPC, syscall.EDQUOT, syscall.EIO},
+	"File.WriteAt":  {syscall.ENOSPC, syscall.EDQUOT, syscall.EIO},
+	"File.Truncate": {syscall.ENOSPC, syscall.EIO},
+}
+
+type chaos struct {
+	config
+	seed int64
+
+	mu     sync.Mutex
+	rand   *rand.Rand
+	calls  int
+	faults []Fault
+}
+
+func (ch *chaos) hook(c hookos.Call, next hookos.Next) []interface{} {
+	res, ok := hookos.Fail(c, nil)
+	if !ok {
+		return next(c)
+	}
+	f, ok := ch.fault(c)
+	if !ok {
+		return next(c)
+	}
+	switch f.Kind {
+	case KindShortRead:
+		c.Args = append([]interface{}{c.Args[0].([]byte)[:f.N]}, c.Args[1:]...)
+		return next(c)
+	case KindPartialWrite:
+		c.Args = append([]interface{}{c.Args[0].([]byte)[:f.N]}, c.Args[1:]...)
+		res := next(c)
+		if err, _ := res[1].(error); err == nil {
+			res[1] = f.Err
+		}
+		return res
+	}
+	res[len(res)-1] = f.Err
+	return res
+}
+
+// fault randomly decides whether and how to fault c.
+func (ch *chaos) fault(c hookos.Call) (Fault, bool) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.calls++
+
+	rate, ok := ch.opRates[c.Op]
+	if !ok {
+		rate = ch.rate
+	}
+	if ch.rand.Float64() >= rate {
+		return Fault{}, false
+	}
+
+	f := Fault{
+		Call:  ch.calls,
+		Op:    c.Op,
+		Paths: c.Paths,
+		Kind:  KindError,
+	}
+	errs := opErrs[c.Op]
+	if len(errs) == 0 {
+		errs = []syscall.Errno{syscall.EIO}
+	}
+	err := errs[ch.rand.Intn(len(errs))]
+
+	switch c.Op {
+	case "File.Read", "File.Write", "File.WriteAt":
+		// File.ReadAt may not read short without an error.
+		if b, _ := c.Args[0].([]byte); len(b) > 1 && ch.rand.Intn(2) == 0 {
+			f.N, f.Requested = 1+ch.rand.Intn(len(b)-1), len(b)
+			f.Kind = KindPartialWrite
+			if c.Op == "File.Read" {
+				f.Kind = KindShortRead
+				err = 0
+			}
+		}
+	}
+	if err != 0 {
+		f.Err = faultos.WrapError(c, err)
+	}
+
+	ch.faults = append(ch.faults, f)
+	if ch.log != nil {
+		fmt.Fprintln(ch.log, f)
+	}
+	return f, true
+}
@@ -0,0 +1,145 @@
+package chaosos_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/chaosos"
+	"github.com/echocrow/osa/oos"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// workload runs a fixed sequence of calls, ignoring any errors.
+func workload(o osa.I, dir string) {
+	for i := 0; i < 20; i++ {
+		name := testos.Join(dir, fmt.Sprint("file", i%4))
+		o.WriteFile(name, []byte("some file contents"), 0644)
+		o.ReadFile(name)
+		o.Rename(name, name+".bak")
+		o.Stat(name + ".bak")
+		if f, err := o.OpenFile(name, os.O_RDWR|os.O_CREATE, 0644); err == nil {
+			f.Write([]byte("more contents"))
+			f.Seek(0, io.SeekStart)
+			f.Read(make([]byte, 8))
+			f.Close()
+		}
+		o.Getenv("KEY")
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	run := func(seed int64) []chaosos.Fault {
+		v := vos.New()
+		c := chaosos.New(v, seed, chaosos.WithRate(0.3))
+		workload(c, vos.MkTempDir(v))
+		assert.Equal(t, seed, c.Seed())
+		return c.Faults()
+	}
+
+	faults := run(42)
+	require.NotEmpty(t, faults)
+	assert.Equal(t, faults, run(42), "replays faults of seed")
+	assert.NotEqual(t, faults, run(43), "injects different faults per seed")
+}
+
+func TestRates(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	c := chaosos.New(v, 1, chaosos.WithRate(0), chaosos.WithOpRate("Rename", 1))
+	workload(c, dir)
+
+	faults := c.Faults()
+	require.NotEmpty(t, faults)
+	for _, f := range faults {
+		assert.Equal(t, "Rename", f.Op)
+		assert.Equal(t, chaosos.KindError, f.Kind)
+		var linkErr *os.LinkError
+		assert.True(t, errors.As(f.Err, &linkErr), f.Err)
+	}
+}
+
+func TestShortIO(t *testing.T) {
+	v := vos.New()
+	file := testos.Join(vos.MkTempDir(v), "file")
+	testos.RequireWrite(t, v, file, "0123456789")
+
+	c := chaosos.New(v, 7, chaosos.WithRate(0), chaosos.WithOpRate("File.Read", 1))
+	f, err := c.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var reads []int
+	var data []byte
+	for {
+		b := make([]byte, 10)
+		n, err := f.Read(b)
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, syscall.EIO) {
+			continue
+		}
+		require.NoError(t, err)
+		reads = append(reads, n)
+		data = append(data, b[:n]...)
+	}
+	assert.Equal(t, "0123456789", string(data))
+	assert.Less(t, reads[0], 10)
+
+	c = chaosos.New(v, 7, chaosos.WithRate(0), chaosos.WithOpRate("File.Write", 1))
+	w, err := c.Create(file)
+	require.NoError(t, err)
+	defer w.Close()
+	for {
+		n, err := w.Write([]byte("abcdef"))
+		if err == nil {
+			t.Fatal("expected fault")
+		}
+		if n > 0 {
+			assert.Less(t, n, 6)
+			assert.True(t, errors.Is(err, syscall.ENOSPC) ||
+				errors.Is(err, syscall.EDQUOT) ||
+				errors.Is(err, syscall.EIO), err)
+			testos.AssertFileData(t, v, file, "abcdef"[:n])
+			faults := c.Faults()
+			f := faults[len(faults)-1]
+			assert.Equal(t, chaosos.KindPartialWrite, f.Kind)
+			assert.Equal(t, n, f.N)
+			assert.Equal(t, 6, f.Requested)
+			assert.Contains(t, f.String(), fmt.Sprintf("of %d of 6 bytes", n))
+			break
+		}
+	}
+}
+
+func TestLog(t *testing.T) {
+	v := vos.New()
+	file := testos.Join(vos.MkTempDir(v), "file")
+	var log bytes.Buffer
+	c := chaosos.New(v, 1, chaosos.WithRate(1), chaosos.WithLog(&log))
+
+	err := c.Mkdir(file, 0755)
+	require.Error(t, err)
+	faults := c.Faults()
+	require.Len(t, faults, 1)
+	assert.Equal(t, 1, faults[0].Call)
+	assert.Equal(t, "Mkdir", faults[0].Op)
+	assert.Equal(t, err, faults[0].Err)
+	assert.Equal(t, fmt.Sprintf("call 1: Mkdir %s: %s\n", file, err), log.String())
+}
+
+func TestWrapOOS(t *testing.T) {
+	dir := t.TempDir()
+	c := chaosos.New(oos.New(), 3, chaosos.WithRate(0.3))
+	workload(c, dir)
+	assert.NotEmpty(t, c.Faults())
+}
@@ -0,0 +1,26 @@
+package osa
+
+import "context"
+
+type ctxKey struct{}
+
+// WithContext returns a copy of ctx that carries the OS abstraction o.
+//
+// Code that retrieves its OS abstraction via FromContext, or that calls the
+// context-aware package funcs (such as ReadFileContext), will use o instead of
+// the process-wide implementation. This allows parallel tests to each drive
+// their own implementation without patching.
+func WithContext(ctx context.Context, o I) context.Context {
+	return context.WithValue(ctx, ctxKey{}, o)
+}
+
+// FromContext returns the OS abstraction carried by ctx, or the current
+// process-wide implementation if ctx carries none.
+func FromContext(ctx context.Context) I {
+	if o, ok := ctx.Value(ctxKey{}).(I); ok {
+		return o
+	}
+	return Current()
+}
+
+//go:generate go run ./gen -call=FromContext(ctx) -ctx
@@ -0,0 +1,46 @@
+package osa_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContextDefault(t *testing.T) {
+	assert.Exactly(t, osa.Current(), osa.FromContext(context.Background()))
+}
+
+func TestWithContext(t *testing.T) {
+	o := &patchOS{}
+	ctx := osa.WithContext(context.Background(), o)
+	assert.Same(t, o, osa.FromContext(ctx))
+}
+
+func TestContextFuncsParallel(t *testing.T) {
+	for i := 0; i < 8; i++ {
+		i := i
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+			v := vos.New()
+			ctx := osa.WithContext(context.Background(), v)
+
+			file := testos.Join(vos.MkTempDir(v), "file")
+			data := fmt.Sprint("data ", i)
+			require.NoError(t, osa.WriteFileContext(ctx, file, []byte(data), 0644))
+
+			got, err := osa.ReadFileContext(ctx, file)
+			require.NoError(t, err)
+			assert.Equal(t, data, string(got))
+			testos.AssertFileData(t, v, file, data)
+
+			require.NoError(t, osa.SetenvContext(ctx, "KEY", data))
+			assert.Equal(t, data, v.Getenv("KEY"))
+		})
+	}
+}
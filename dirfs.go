@@ -0,0 +1,93 @@
+package osa
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// DirFS returns a file system for the tree of files rooted at the directory
+// dir of the OS abstraction implementation o, similar to os.DirFS.
+//
+// The file system implements fs.StatFS, fs.ReadDirFS, fs.ReadFileFS,
+// fs.SubFS, and fs.GlobFS, e.g. to pass the files of o to template.ParseFS,
+// http.FS, or fs.WalkDir. As required by fs.FS, names are slash-separated
+// paths relative to dir, and invalid names fail with fs.ErrInvalid.
+func DirFS(o I, dir string) fs.FS {
+	return dirFS{o, dir}
+}
+
+type dirFS struct {
+	o   I
+	dir string
+}
+
+// join returns the OS path of name, or an error if name is invalid.
+func (d dirFS) join(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(d.dir, filepath.FromSlash(name)), nil
+}
+
+func (d dirFS) Open(name string) (fs.File, error) {
+	p, err := d.join("open", name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := d.o.Open(p)
+	return f, dirFSError(err, name)
+}
+
+func (d dirFS) Stat(name string) (fs.FileInfo, error) {
+	p, err := d.join("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := d.o.Stat(p)
+	return fi, dirFSError(err, name)
+}
+
+func (d dirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := d.join("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := d.o.ReadDir(p)
+	return entries, dirFSError(err, name)
+}
+
+func (d dirFS) ReadFile(name string) ([]byte, error) {
+	p, err := d.join("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.o.ReadFile(p)
+	return data, dirFSError(err, name)
+}
+
+// Sub returns the file system rooted at the directory dir.
+func (d dirFS) Sub(dir string) (fs.FS, error) {
+	p, err := d.join("sub", dir)
+	if err != nil {
+		return nil, err
+	}
+	if dir == "." {
+		return d, nil
+	}
+	return dirFS{d.o, p}, nil
+}
+
+// Glob returns the names of all files matching pattern, as fs.Glob does.
+func (d dirFS) Glob(pattern string) ([]string, error) {
+	// Hide Glob from fs.Glob to use its generic implementation.
+	return fs.Glob(struct{ fs.ReadDirFS }{d}, pattern)
+}
+
+// dirFSError replaces the OS path of a path error with the name passed to the
+// file system, as fs.FS requires.
+func dirFSError(err error, name string) error {
+	if pe, ok := err.(*PathError); ok {
+		return &PathError{Op: pe.Op, Path: name, Err: pe.Err}
+	}
+	return err
+}
@@ -0,0 +1,77 @@
+package osa_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/oos"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dirFSTree = testos.Tree{
+	"a.txt":       "a",
+	"dir/b.txt":   "b",
+	"dir/c/d.txt": "d",
+	"empty/":      testos.Dir{},
+}
+
+func TestDirFS(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		o    osa.I
+		dir  func(o osa.I) string
+	}{
+		{"vos", vos.New(), func(o osa.I) string { return testos.RequireTempDir(t, o) }},
+		{"oos", oos.New(), func(osa.I) string { return t.TempDir() }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := tc.dir(tc.o)
+			dirFSTree.Write(t, tc.o, dir)
+			fsys := osa.DirFS(tc.o, dir)
+			require.NoError(t, fstest.TestFS(fsys, "a.txt", "dir/b.txt", "dir/c/d.txt", "empty"))
+
+			sub, err := fs.Sub(fsys, "dir")
+			require.NoError(t, err)
+			require.NoError(t, fstest.TestFS(sub, "b.txt", "c/d.txt"))
+
+			matches, err := fs.Glob(fsys, "dir/*.txt")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"dir/b.txt"}, matches)
+
+			_, err = fs.ReadFile(fsys, "missing")
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+			var pe *fs.PathError
+			require.ErrorAs(t, err, &pe)
+			assert.Equal(t, "missing", pe.Path)
+
+			_, err = fsys.Open("/a.txt")
+			assert.ErrorIs(t, err, fs.ErrInvalid)
+		})
+	}
+}
+
+func TestDirFSWalk(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	dirFSTree.Write(t, v, dir)
+	var got []string
+	err := fs.WalkDir(osa.DirFS(v, dir), ".", func(p string, d fs.DirEntry, err error) error {
+		got = append(got, p)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{".", "a.txt", "dir", "dir/b.txt", "dir/c", "dir/c/d.txt", "empty"}, got)
+
+	real := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(real, "x"), []byte("x"), 0644))
+	data, err := fs.ReadFile(osa.DirFS(oos.New(), real), "x")
+	require.NoError(t, err)
+	assert.Equal(t, "x", string(data))
+}
@@ -0,0 +1,21 @@
+package osa
+
+// Env describes a set of functions that read and modify environment
+// variables.
+type Env interface {
+	// Getenv retrieves the value of the environment variable named by the key.
+	Getenv(key string) string
+	// LookupEnv retrieves the value of the environment variable named by the
+	// key and reports whether the variable is present.
+	LookupEnv(key string) (string, bool)
+	// Setenv sets the value of the environment variable named by the key.
+	Setenv(key, value string) error
+	// Unsetenv unsets a single environment variable.
+	Unsetenv(key string) error
+	// Environ returns a copy of strings representing the environment, in the
+	// form "key=value".
+	Environ() []string
+	// ExpandEnv replaces ${var} or $var in the string according to the values
+	// of the current environment variables.
+	ExpandEnv(s string) string
+}
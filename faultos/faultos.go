@@ -0,0 +1,265 @@
+// Package faultos provides an OS abstraction implementation that injects
+// faults into calls to another implementation.
+//
+// Faults are described by rules that match calls by op, path, and call count:
+//
+//	f := faultos.New(vos.New(),
+//		// Fail the 3rd write of any database file with ENOSPC.
+//		faultos.Rule{Op: "WriteFile", Path: "/data/*.db", Call: 3, Err: syscall.ENOSPC},
+//		// Fail every rename.
+//		faultos.Rule{Op: "Rename", Err: syscall.EXDEV},
+//	)
+//
+// Injected errors are wrapped like the errors of the os package, e.g. in a
+// *PathError or *LinkError.
+//
+// Faults are atomic: a failed call does not reach the wrapped implementation,
+// and thus has no effect. E.g., a WriteFile failing with ENOSPC neither
+// creates nor truncates the file, whereas the os package may leave a
+// truncated or partially written file behind.
+package faultos
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/hookos"
+)
+
+// Rule describes a fault to inject into matching calls.
+type Rule struct {
+	// Op is the name of the method to fail, e.g. "WriteFile". Methods called
+	// on files are prefixed with "File.", e.g. "File.Write", and methods
+	// called on stdio with their stream, i.e. "Stdin.Read", "Stdout.Write",
+	// and "Stderr.Write". An empty Op matches all methods.
+	Op string
+	// Path is a pattern (see filepath.Match) that any path of the call must
+	// match, e.g. "/data/*.db". Stdio calls match by the paths "/dev/stdin",
+	// "/dev/stdout", and "/dev/stderr". An empty Path matches all calls.
+	Path string
+	// Call is the number of the matching call to fail, starting at 1. Zero
+	// fails all matching calls.
+	Call int
+	// Err is the error to inject, typically a syscall.Errno such as
+	// syscall.ENOSPC. Defaults to syscall.EIO.
+	Err error
+}
+
+type faultos struct {
+	osa.I
+	inj *injector
+}
+
+// New creates a new faultos instance that wraps o and injects faults as
+// described by rules.
+//
+// Methods that do not return an error, such as Getenv, are never failed.
+func New(o osa.I, rules ...Rule) faultos {
+	inj := &injector{}
+	inj.add(rules)
+	return faultos{hookos.New(o, inj.hook), inj}
+}
+
+// Inject adds rules to the faultos instance.
+func (f faultos) Inject(rules ...Rule) {
+	f.inj.add(rules)
+}
+
+// Reset removes all rules from the faultos instance.
+func (f faultos) Reset() {
+	f.inj.reset()
+}
+
+type rule struct {
+	Rule
+	calls int
+}
+
+func (r rule) matches(c hookos.Call) bool {
+	if r.Op != "" && r.Op != c.Op {
+		return false
+	}
+	if r.Path == "" {
+		return true
+	}
+	paths := c.Paths
+	if p, ok := stdioPaths[c.Op]; ok {
+		paths = []string{p}
+	}
+	for _, p := range paths {
+		if ok, _ := filepath.Match(r.Path, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type injector struct {
+	mu    sync.Mutex
+	rules []*rule
+}
+
+func (inj *injector) add(rules []Rule) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	for _, r := range rules {
+		inj.rules = append(inj.rules, &rule{Rule: r})
+	}
+}
+
+func (inj *injector) reset() {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.rules = nil
+}
+
+// fault returns the error to inject into c, if any.
+func (inj *injector) fault(c hookos.Call) error {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	var err error
+	for _, r := range inj.rules {
+		if !r.matches(c) {
+			continue
+		}
+		r.calls++
+		if err == nil && (r.Call == 0 || r.Call == r.calls) {
+			err = r.Err
+			if err == nil {
+				err = syscall.EIO
+			}
+		}
+	}
+	return err
+}
+
+func (inj *injector) hook(c hookos.Call, next hookos.Next) []interface{} {
+	res, ok := hookos.Fail(c, nil)
+	if !ok {
+		return next(c)
+	}
+	err := inj.fault(c)
+	if err == nil {
+		return next(c)
+	}
+	res[len(res)-1] = WrapError(c, err)
+	return res
+}
+
+// errOps maps methods to the op of the *PathError the os package returns.
+var errOps = map[string]string{
+	"Open":          "open",
+	"OpenFile":      "open",
+	"Create":        "open",
+	"Stat":          "stat",
+	"Lstat":         "lstat",
+	"Mkdir":         "mkdir",
+	"MkdirAll":      "mkdir",
+	"MkdirTemp":     "mkdirtemp",
+	"ReadDir":       "open",
+	"WriteFile":     "open",
+	"ReadFile":      "open",
+	"Remove":        "remove",
+	"RemoveAll":     "unlinkat",
+	"Readlink":      "readlink",
+	"Chmod":         "chmod",
+	"Chtimes":       "chtimes",
+	"Chdir":         "chdir",
+	"File.Read":     "read",
+	"File.ReadAt":   "read",
+	"File.Write":    "write",
+	"File.WriteAt":  "write",
+	"File.Seek":     "seek",
+	"File.Truncate": "truncate",
+	"File.Sync":     "sync",
+	"File.Close":    "close",
+	"File.Stat":     "stat",
+	"File.ReadDir":  "readdirent",
+	"Stdin.Read":    "read",
+	"Stdout.Write":  "write",
+	"Stderr.Write":  "write",
+}
+
+// stdioPaths maps stdio methods to the paths of their streams.
+var stdioPaths = map[string]string{
+	"Stdin.Read":   "/dev/stdin",
+	"Stdout.Write": "/dev/stdout",
+	"Stderr.Write": "/dev/stderr",
+}
+
+// linkOps maps methods to the op of the *LinkError the os package returns.
+var linkOps = map[string]string{
+	"Rename":  "rename",
+	"Link":    "link",
+	"Symlink": "symlink",
+}
+
+// syscallOps maps methods to the syscall of the *SyscallError the os package
+// returns.
+var syscallOps = map[string]string{
+	"Getwd":    "getwd",
+	"Setenv":   "setenv",
+	"Unsetenv": "unsetenv",
+}
+
+// dataOps maps methods that open, then read or write files, to the op of the
+// *PathError the os package returns when reading or writing fails.
+var dataOps = map[string]string{
+	"WriteFile": "write",
+	"ReadFile":  "read",
+}
+
+// WrapError wraps err like the os package would for the method of c, e.g. in
+// a *PathError. Errors that are already wrapped are returned as is.
+func WrapError(c hookos.Call, err error) error {
+	var (
+		pathErr    *os.PathError
+		linkErr    *os.LinkError
+		syscallErr *os.SyscallError
+	)
+	if errors.As(err, &pathErr) ||
+		errors.As(err, &linkErr) ||
+		errors.As(err, &syscallErr) {
+		return err
+	}
+
+	path := func(i int) string {
+		if i < len(c.Paths) {
+			return c.Paths[i]
+		}
+		return stdioPaths[c.Op]
+	}
+	if op, ok := linkOps[c.Op]; ok {
+		return &os.LinkError{Op: op, Old: path(0), New: path(1), Err: err}
+	}
+	if op, ok := syscallOps[c.Op]; ok {
+		return os.NewSyscallError(op, err)
+	}
+	if op, ok := dataOps[c.Op]; ok && isDataErr(err) {
+		return &os.PathError{Op: op, Path: path(0), Err: err}
+	}
+	if op, ok := errOps[c.Op]; ok {
+		return &os.PathError{Op: op, Path: path(0), Err: err}
+	}
+	return err
+}
+
+// isDataErr reports whether err typically occurs when reading or writing file
+// contents, rather than when opening a file.
+func isDataErr(err error) bool {
+	for _, dataErr := range []error{
+		syscall.EIO,
+		syscall.ENOSPC,
+		syscall.EDQUOT,
+		syscall.EFBIG,
+	} {
+		if errors.Is(err, dataErr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package faultos_test
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/echocrow/osa/faultos"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNthCall(t *testing.T) {
+	v := vos.New()
+	testos.RequireMkdirAll(t, v, "/data")
+	f := faultos.New(v, faultos.Rule{
+		Op:   "WriteFile",
+		Path: "/data/*.db",
+		Call: 3,
+		Err:  syscall.ENOSPC,
+	})
+
+	require.NoError(t, f.WriteFile("/data/other.txt", nil, 0644))
+	require.NoError(t, f.WriteFile("/data/a.db", nil, 0644))
+	require.NoError(t, f.WriteFile("/data/b.db", nil, 0644))
+
+	err := f.WriteFile("/data/c.db", nil, 0644)
+	assert.Equal(t, &os.PathError{Op: "write", Path: "/data/c.db", Err: syscall.ENOSPC}, err)
+	assert.True(t, errors.Is(err, syscall.ENOSPC))
+	testos.AssertNotExists(t, v, "/data/c.db")
+
+	assert.NoError(t, f.WriteFile("/data/d.db", nil, 0644))
+
+	// Failed calls have no effect.
+	f.Inject(faultos.Rule{Op: "WriteFile", Err: syscall.ENOSPC})
+	testos.RequireWrite(t, v, "/data/e.db", "data")
+	err = f.WriteFile("/data/e.db", []byte("new data"), 0644)
+	assert.True(t, errors.Is(err, syscall.ENOSPC))
+	testos.AssertFileData(t, v, "/data/e.db", "data")
+}
+
+func TestAlways(t *testing.T) {
+	v := vos.New()
+	testos.RequireEmptyWrite(t, v, "file")
+	f := faultos.New(v, faultos.Rule{Op: "Rename", Err: syscall.EXDEV})
+
+	for i := 0; i < 3; i++ {
+		err := f.Rename("file", "other")
+		assert.Equal(t, &os.LinkError{Op: "rename", Old: "file", New: "other", Err: syscall.EXDEV}, err)
+	}
+	testos.AssertExists(t, v, "file")
+}
+
+func TestErrors(t *testing.T) {
+	v := vos.New()
+	testos.RequireEmptyWrite(t, v, "file")
+	f := faultos.New(v)
+
+	t.Run("PathError", func(t *testing.T) {
+		f.Reset()
+		f.Inject(faultos.Rule{Op: "Open", Err: syscall.EACCES})
+		_, err := f.Open("file")
+		assert.Equal(t, &os.PathError{Op: "open", Path: "file", Err: syscall.EACCES}, err)
+		assert.True(t, f.IsPermission(err))
+	})
+	t.Run("SyscallError", func(t *testing.T) {
+		f.Reset()
+		f.Inject(faultos.Rule{Op: "Setenv", Err: syscall.ENOMEM})
+		err := f.Setenv("KEY", "val")
+		assert.Equal(t, os.NewSyscallError("setenv", syscall.ENOMEM), err)
+	})
+	t.Run("Default", func(t *testing.T) {
+		f.Reset()
+		f.Inject(faultos.Rule{Op: "Stat"})
+		_, err := f.Stat("file")
+		assert.Equal(t, &os.PathError{Op: "stat", Path: "file", Err: syscall.EIO}, err)
+	})
+	t.Run("Custom", func(t *testing.T) {
+		f.Reset()
+		want := &os.PathError{Op: "custom", Path: "path", Err: syscall.EINVAL}
+		f.Inject(faultos.Rule{Op: "Remove", Err: want})
+		assert.Same(t, want, f.Remove("file"))
+	})
+	t.Run("NoError", func(t *testing.T) {
+		f.Reset()
+		f.Inject(faultos.Rule{})
+		assert.NotPanics(t, func() { f.Getenv("KEY") })
+		assert.Error(t, f.Chmod("file", 0600))
+	})
+}
+
+func TestFileOps(t *testing.T) {
+	v := vos.New()
+	f := faultos.New(v, faultos.Rule{Op: "File.Write", Path: "*.log", Call: 2, Err: syscall.EIO})
+
+	file, err := f.Create("app.log")
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.Write([]byte("one"))
+	assert.NoError(t, err)
+	n, err := file.Write([]byte("two"))
+	assert.Zero(t, n)
+	assert.Equal(t, &os.PathError{Op: "write", Path: "app.log", Err: syscall.EIO}, err)
+	_, err = file.Write([]byte("three"))
+	assert.NoError(t, err)
+
+	testos.AssertFileData(t, v, "app.log", "onethree")
+}
+
+func TestStdio(t *testing.T) {
+	v := vos.New()
+	f := faultos.New(v, faultos.Rule{Op: "Stdout.Write", Err: syscall.EPIPE})
+
+	n, err := f.Stdout().Write([]byte("data"))
+	assert.Zero(t, n)
+	assert.Equal(t, &os.PathError{Op: "write", Path: "/dev/stdout", Err: syscall.EPIPE}, err)
+
+	_, err = f.Stderr().Write([]byte("data"))
+	assert.NoError(t, err)
+
+	f.Reset()
+	f.Inject(faultos.Rule{Path: "/dev/std[ie]*"})
+	stdin, _, _ := vos.GetStdio(v)
+	_, err = stdin.Write([]byte("input"))
+	require.NoError(t, err)
+	_, err = f.Stdin().Read(make([]byte, 5))
+	assert.Equal(t, &os.PathError{Op: "read", Path: "/dev/stdin", Err: syscall.EIO}, err)
+	_, err = f.Stderr().Write([]byte("data"))
+	assert.Equal(t, &os.PathError{Op: "write", Path: "/dev/stderr", Err: syscall.EIO}, err)
+	_, err = f.Stdout().Write([]byte("data"))
+	assert.NoError(t, err)
+}
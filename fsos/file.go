@@ -0,0 +1,69 @@
+package fsos
+
+import (
+	"io"
+	"io/fs"
+	"syscall"
+)
+
+// file is a read-only file opened from a fs.FS.
+type file struct {
+	fs.File
+	name string
+	// fsName is the name of the file within the fs.FS.
+	fsName string
+	wd     *workDir
+}
+
+func (f *file) Name() string {
+	return f.name
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	fi, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{fi, f.fsName, f.wd}, nil
+}
+
+func (f *file) ReadAt(b []byte, off int64) (int, error) {
+	r, ok := f.File.(io.ReaderAt)
+	if !ok {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: syscall.EINVAL}
+	}
+	return r.ReadAt(b, off)
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	s, ok := f.File.(io.Seeker)
+	if !ok {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: syscall.ESPIPE}
+	}
+	return s.Seek(offset, whence)
+}
+
+func (f *file) ReadDir(n int) ([]fs.DirEntry, error) {
+	d, ok := f.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdirent", Path: f.name, Err: syscall.ENOTDIR}
+	}
+	return d.ReadDir(n)
+}
+
+func (f *file) Write(b []byte) (int, error) {
+	return 0, readOnly("write", f.name)
+}
+
+func (f *file) WriteAt(b []byte, off int64) (int, error) {
+	return 0, readOnly("write", f.name)
+}
+
+func (f *file) Truncate(size int64) error {
+	return readOnly("truncate", f.name)
+}
+
+// Sync does nothing, as the file has no changes to commit.
+func (f *file) Sync() error {
+	return nil
+}
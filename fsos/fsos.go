@@ -0,0 +1,309 @@
+// Package fsos provides a read-only OS abstraction implementation backed by
+// any fs.FS, such as embed.FS, zip.Reader, or fstest.MapFS.
+//
+// The file system is mounted at the root directory: "/etc/app.toml" and, from
+// the initial working directory "/", "etc/app.toml" both name the file
+// "etc/app.toml" of the fs.FS. Calls that would modify the file system fail
+// with ErrReadOnly:
+//
+//	//go:embed defaults
+//	var defaults embed.FS
+//
+//	testos.Patch(t, fsos.New(defaults, vos.New()))
+//	cfg, err := config.Load("/defaults/app.toml")
+//
+// Stdio, environment variables, user directories, and Exit are passed to
+// another implementation.
+package fsos
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/oos"
+)
+
+// ErrReadOnly reports an attempt to modify the read-only file system. It
+// matches both fs.ErrPermission and syscall.EROFS via errors.Is.
+var ErrReadOnly error = readOnlyError{}
+
+type readOnlyError struct{}
+
+func (readOnlyError) Error() string { return "read-only file system" }
+
+func (readOnlyError) Is(target error) bool {
+	return target == fs.ErrPermission || target == syscall.EROFS
+}
+
+type fsos struct {
+	osa.Env
+	osa.Stdio
+	o    osa.I
+	fsys fs.FS
+	wd   *workDir
+}
+
+// workDir holds the working directory of a fsos instance.
+type workDir struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// New creates a new fsos instance serving the files of fsys.
+//
+// Stdio, environment variables, user directories, and Exit are passed to o.
+// If o is nil, they are passed to the standard implementation.
+func New(fsys fs.FS, o osa.I) fsos {
+	if o == nil {
+		o = oos.New()
+	}
+	return fsos{o, o, o, fsys, &workDir{dir: string(filepath.Separator)}}
+}
+
+// name returns the name within the fs.FS of an OS path.
+func (f fsos) name(p string) (string, error) {
+	if p == "" {
+		return "", fs.ErrNotExist
+	}
+	if !filepath.IsAbs(p) {
+		f.wd.mu.Lock()
+		p = filepath.Join(f.wd.dir, p)
+		f.wd.mu.Unlock()
+	}
+	name := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(p)), "/")
+	if name == "" {
+		name = "."
+	}
+	return name, nil
+}
+
+// pathError returns err with its path replaced by the OS path p.
+func pathError(op, p string, err error) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		err = pe.Err
+	}
+	return &fs.PathError{Op: op, Path: p, Err: err}
+}
+
+func readOnly(op, p string) error {
+	return &fs.PathError{Op: op, Path: p, Err: ErrReadOnly}
+}
+
+func readOnlyLink(op, oldname, newname string) error {
+	return &os.LinkError{Op: op, Old: oldname, New: newname, Err: ErrReadOnly}
+}
+
+func (f fsos) Open(p string) (fs.File, error) {
+	return f.OpenFile(p, os.O_RDONLY, 0)
+}
+
+func (f fsos) OpenFile(p string, flag int, perm os.FileMode) (osa.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, readOnly("open", p)
+	}
+	name, err := f.name(p)
+	if err != nil {
+		return nil, pathError("open", p, err)
+	}
+	fl, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, pathError("open", p, err)
+	}
+	return &file{File: fl, name: p, fsName: name, wd: f.wd}, nil
+}
+
+func (f fsos) Create(p string) (osa.File, error) {
+	return nil, readOnly("open", p)
+}
+
+func (f fsos) Stat(p string) (os.FileInfo, error) {
+	return f.stat("stat", p)
+}
+
+// Lstat returns a FileInfo describing the named file. As fs.FS does not
+// expose symbolic links, it is equivalent to Stat.
+func (f fsos) Lstat(p string) (os.FileInfo, error) {
+	return f.stat("lstat", p)
+}
+
+func (f fsos) stat(op, p string) (os.FileInfo, error) {
+	name, err := f.name(p)
+	if err != nil {
+		return nil, pathError(op, p, err)
+	}
+	fi, err := fs.Stat(f.fsys, name)
+	if err != nil {
+		return nil, pathError(op, p, err)
+	}
+	return fileInfo{fi, name, f.wd}, nil
+}
+
+// fileInfo is a FileInfo that identifies its file for SameFile.
+type fileInfo struct {
+	fs.FileInfo
+	// name is the name of the file within the fs.FS.
+	name string
+	// wd identifies the fsos instance of the file.
+	wd *workDir
+}
+
+func (f fsos) IsExist(err error) bool {
+	return os.IsExist(err)
+}
+
+func (f fsos) IsNotExist(err error) bool {
+	return os.IsNotExist(err)
+}
+
+func (f fsos) IsPermission(err error) bool {
+	return os.IsPermission(err) || errors.Is(err, ErrReadOnly)
+}
+
+func (f fsos) PathSeparator() uint8 {
+	return os.PathSeparator
+}
+
+func (f fsos) IsPathSeparator(c uint8) bool {
+	return os.IsPathSeparator(c)
+}
+
+func (f fsos) Mkdir(p string, perm os.FileMode) error {
+	return readOnly("mkdir", p)
+}
+
+// MkdirAll succeeds if the named directory exists, as it has nothing to
+// create.
+func (f fsos) MkdirAll(p string, perm os.FileMode) error {
+	if fi, err := f.Stat(p); err == nil && fi.IsDir() {
+		return nil
+	}
+	return readOnly("mkdir", p)
+}
+
+func (f fsos) MkdirTemp(dir, pattern string) (string, error) {
+	return "", readOnly("mkdirtemp", filepath.Join(dir, pattern))
+}
+
+func (f fsos) ReadDir(p string) ([]os.DirEntry, error) {
+	name, err := f.name(p)
+	if err != nil {
+		return nil, pathError("open", p, err)
+	}
+	entries, err := fs.ReadDir(f.fsys, name)
+	if err != nil {
+		return entries, pathError("readdirent", p, err)
+	}
+	return entries, nil
+}
+
+func (f fsos) WriteFile(p string, data []byte, perm os.FileMode) error {
+	return readOnly("open", p)
+}
+
+func (f fsos) ReadFile(p string) ([]byte, error) {
+	name, err := f.name(p)
+	if err != nil {
+		return nil, pathError("open", p, err)
+	}
+	data, err := fs.ReadFile(f.fsys, name)
+	if err != nil {
+		return nil, pathError("read", p, err)
+	}
+	return data, nil
+}
+
+func (f fsos) Rename(oldpath, newpath string) error {
+	return readOnlyLink("rename", oldpath, newpath)
+}
+
+func (f fsos) Remove(p string) error {
+	return readOnly("remove", p)
+}
+
+// RemoveAll succeeds if the named path does not exist, as it has nothing to
+// remove.
+func (f fsos) RemoveAll(p string) error {
+	if _, err := f.Lstat(p); os.IsNotExist(err) {
+		return nil
+	}
+	return readOnly("unlinkat", p)
+}
+
+func (f fsos) Link(oldname, newname string) error {
+	return readOnlyLink("link", oldname, newname)
+}
+
+// SameFile reports whether fi1 and fi2 describe the same file. As fs.FS does
+// not expose file identities, files are identified by their cleaned names
+// within the fs.FS. It reports false for FileInfos that were not returned by
+// Stat, Lstat, or File.Stat of the same fsos instance.
+func (f fsos) SameFile(fi1, fi2 os.FileInfo) bool {
+	a, ok1 := fi1.(fileInfo)
+	b, ok2 := fi2.(fileInfo)
+	return ok1 && ok2 && a.wd == b.wd && a.name == b.name
+}
+
+func (f fsos) Symlink(oldname, newname string) error {
+	return readOnlyLink("symlink", oldname, newname)
+}
+
+// Readlink always fails, as fs.FS does not expose symbolic links.
+func (f fsos) Readlink(p string) (string, error) {
+	if _, err := f.stat("readlink", p); err != nil {
+		return "", err
+	}
+	return "", &fs.PathError{Op: "readlink", Path: p, Err: syscall.EINVAL}
+}
+
+func (f fsos) Chmod(p string, mode os.FileMode) error {
+	return readOnly("chmod", p)
+}
+
+func (f fsos) Chtimes(p string, atime time.Time, mtime time.Time) error {
+	return readOnly("chtimes", p)
+}
+
+func (f fsos) Getwd() (string, error) {
+	f.wd.mu.Lock()
+	defer f.wd.mu.Unlock()
+	return f.wd.dir, nil
+}
+
+func (f fsos) Chdir(dir string) error {
+	fi, err := f.stat("chdir", dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return &fs.PathError{Op: "chdir", Path: dir, Err: syscall.ENOTDIR}
+	}
+	name, _ := f.name(dir)
+	f.wd.mu.Lock()
+	defer f.wd.mu.Unlock()
+	f.wd.dir = filepath.Join(string(filepath.Separator), filepath.FromSlash(name))
+	return nil
+}
+
+func (f fsos) UserCacheDir() (string, error) {
+	return f.o.UserCacheDir()
+}
+
+func (f fsos) UserConfigDir() (string, error) {
+	return f.o.UserConfigDir()
+}
+
+func (f fsos) UserHomeDir() (string, error) {
+	return f.o.UserHomeDir()
+}
+
+func (f fsos) Exit(code int) {
+	f.o.Exit(code)
+}
@@ -0,0 +1,204 @@
+package fsos_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/fsos"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var mapFS = fstest.MapFS{
+	"etc/app.toml":   {Data: []byte("name = \"app\"\n"), Mode: 0444},
+	"etc/conf.d/x":   {Data: []byte("x")},
+	"share/data.bin": {Data: []byte{0, 1, 2, 3}},
+}
+
+func TestSameFile(t *testing.T) {
+	f := fsos.New(fstest.MapFS{
+		"a/config.toml": {Data: []byte("x")},
+		"b/config.toml": {Data: []byte("x")},
+	}, vos.New())
+
+	a, err := f.Stat("/a/config.toml")
+	require.NoError(t, err)
+	b, err := f.Stat("/b/config.toml")
+	require.NoError(t, err)
+	assert.False(t, f.SameFile(a, b))
+
+	require.NoError(t, f.Chdir("/b"))
+	file, err := f.Open("../a/config.toml")
+	require.NoError(t, err)
+	defer file.Close()
+	a2, err := file.Stat()
+	require.NoError(t, err)
+	assert.True(t, f.SameFile(a, a2))
+
+	other := fsos.New(fstest.MapFS{"a/config.toml": {Data: []byte("x")}}, vos.New())
+	a3, err := other.Stat("/a/config.toml")
+	require.NoError(t, err)
+	assert.False(t, f.SameFile(a, a3))
+	assert.False(t, f.SameFile(a, nil))
+}
+
+func TestRead(t *testing.T) {
+	f := fsos.New(mapFS, vos.New())
+	testos.AssertFileData(t, f, "/etc/app.toml", "name = \"app\"\n")
+	testos.AssertFileData(t, f, "etc/conf.d/x", "x")
+	testos.AssertFileData(t, f, "/share/../etc/conf.d/x", "x")
+	testos.AssertNotExists(t, f, "/missing")
+
+	fi, err := f.Stat("/etc/app.toml")
+	require.NoError(t, err)
+	assert.Equal(t, "app.toml", fi.Name())
+	assert.Equal(t, fs.FileMode(0444), fi.Mode())
+	fi2, err := f.Lstat("etc/app.toml")
+	require.NoError(t, err)
+	assert.True(t, f.SameFile(fi, fi2))
+	fi3, err := f.Stat("/etc/conf.d/x")
+	require.NoError(t, err)
+	assert.False(t, f.SameFile(fi, fi3))
+
+	entries, err := f.ReadDir("/etc")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "app.toml", entries[0].Name())
+	assert.True(t, entries[1].IsDir())
+
+	_, err = f.ReadFile("/missing")
+	assert.True(t, f.IsNotExist(err))
+	var pe *fs.PathError
+	require.ErrorAs(t, err, &pe)
+	assert.Equal(t, "/missing", pe.Path)
+
+	_, err = f.Readlink("/etc/app.toml")
+	assert.ErrorIs(t, err, syscall.EINVAL)
+}
+
+func TestChdir(t *testing.T) {
+	f := fsos.New(mapFS, vos.New())
+	wd, err := f.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, "/", wd)
+
+	require.NoError(t, f.Chdir("etc"))
+	require.NoError(t, f.Chdir("conf.d"))
+	wd, err = f.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/conf.d", wd)
+	testos.AssertFileData(t, f, "x", "x")
+	testos.AssertFileData(t, f, "../app.toml", "name = \"app\"\n")
+
+	assert.Error(t, f.Chdir("x"))
+	assert.True(t, f.IsNotExist(f.Chdir("/missing")))
+}
+
+func TestFile(t *testing.T) {
+	f := fsos.New(mapFS, vos.New())
+	file, err := f.OpenFile("/share/data.bin", osa.O_RDONLY, 0)
+	require.NoError(t, err)
+	defer file.Close()
+	assert.Equal(t, "/share/data.bin", file.Name())
+
+	b := make([]byte, 2)
+	_, err = file.ReadAt(b, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2, 3}, b)
+	_, err = file.Seek(1, io.SeekStart)
+	require.NoError(t, err)
+	_, err = io.ReadFull(file, b)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, b)
+
+	_, err = file.Write(b)
+	assert.ErrorIs(t, err, fsos.ErrReadOnly)
+	_, err = file.WriteAt(b, 0)
+	assert.ErrorIs(t, err, fsos.ErrReadOnly)
+	assert.ErrorIs(t, file.Truncate(0), fsos.ErrReadOnly)
+	assert.NoError(t, file.Sync())
+
+	dir, err := f.Open("/etc")
+	require.NoError(t, err)
+	defer dir.Close()
+	entries, err := dir.(osa.File).ReadDir(-1)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestReadOnly(t *testing.T) {
+	f := fsos.New(mapFS, vos.New())
+	assertReadOnly := func(err error) {
+		t.Helper()
+		assert.ErrorIs(t, err, fsos.ErrReadOnly)
+		assert.ErrorIs(t, err, syscall.EROFS)
+		assert.ErrorIs(t, err, fs.ErrPermission)
+		assert.True(t, f.IsPermission(err))
+	}
+	_, err := f.OpenFile("/etc/app.toml", osa.O_RDWR, 0)
+	assertReadOnly(err)
+	_, err = f.OpenFile("/new", osa.O_WRONLY|osa.O_CREATE, 0644)
+	assertReadOnly(err)
+	_, err = f.Create("/new")
+	assertReadOnly(err)
+	assertReadOnly(f.WriteFile("/new", nil, 0644))
+	assertReadOnly(f.Mkdir("/new", 0755))
+	assertReadOnly(f.MkdirAll("/etc/new", 0755))
+	_, err = f.MkdirTemp("", "")
+	assertReadOnly(err)
+	assertReadOnly(f.Rename("/etc/app.toml", "/new"))
+	assertReadOnly(f.Remove("/etc/app.toml"))
+	assertReadOnly(f.RemoveAll("/etc"))
+	assertReadOnly(f.Link("/etc/app.toml", "/new"))
+	assertReadOnly(f.Symlink("/etc/app.toml", "/new"))
+	assertReadOnly(f.Chmod("/etc/app.toml", 0644))
+	assertReadOnly(f.Chtimes("/etc/app.toml", time.Now(), time.Now()))
+
+	// Calls with nothing to change succeed.
+	assert.NoError(t, f.MkdirAll("/etc/conf.d", 0755))
+	assert.NoError(t, f.RemoveAll("/missing"))
+	testos.AssertFileData(t, f, "/etc/app.toml", "name = \"app\"\n")
+}
+
+func TestDelegates(t *testing.T) {
+	v := vos.New(vos.WithEnv(map[string]string{"APP": "x"}))
+	f := fsos.New(mapFS, v)
+
+	assert.Equal(t, "x", f.Getenv("APP"))
+	require.NoError(t, f.Setenv("APP", "y"))
+	assert.Equal(t, "y", v.Getenv("APP"))
+
+	_, err := f.Stdout().Write([]byte("out"))
+	require.NoError(t, err)
+	_, stdout, _ := vos.GetStdio(v)
+	out, err := io.ReadAll(stdout)
+	require.NoError(t, err)
+	assert.Equal(t, "out", string(out))
+
+	home, err := f.UserHomeDir()
+	require.NoError(t, err)
+	vHome, _ := v.UserHomeDir()
+	assert.Equal(t, vHome, home)
+
+	code := -1
+	func() {
+		defer vos.CatchExit(func(c int) { code = c })
+		f.Exit(3)
+	}()
+	assert.Equal(t, 3, code)
+}
+
+func TestDirFS(t *testing.T) {
+	f := fsos.New(mapFS, nil)
+	err := fstest.TestFS(osa.DirFS(f, "/"), "etc/app.toml", "etc/conf.d/x", "share/data.bin")
+	assert.NoError(t, err)
+	assert.False(t, errors.Is(err, fsos.ErrReadOnly))
+}
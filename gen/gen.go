@@ -28,7 +28,8 @@ const (
 )
 
 var knownImports = map[string]string{
-	"fs": "io/fs",
+	"fs":   "io/fs",
+	"time": "time",
 }
 
 var (
@@ -37,6 +38,8 @@ var (
 	osaParent string
 	importPkg string
 	outDir    string
+	withCtx   bool
+	withHook  bool
 )
 
 func main() {
@@ -45,6 +48,8 @@ func main() {
 	flag.StringVar(&osaParent, "call", "", "name of the parent")
 	flag.StringVar(&importPkg, "import", "", "import packages")
 	flag.StringVar(&outDir, "out", ".", "path to the output dir")
+	flag.BoolVar(&withCtx, "ctx", false, "generate context-aware funcs")
+	flag.BoolVar(&withHook, "hook", false, "generate hooked wrapper methods")
 
 	flag.Parse()
 
@@ -65,6 +70,9 @@ func main() {
 	if importPkg != "" {
 		imports.Add(importPkg)
 	}
+	if withCtx {
+		imports.Add("context")
+	}
 
 	outTpl := heredoc.Doc(`
 		package {{.Pkg}}
@@ -84,7 +92,13 @@ func main() {
 			{{if .Returns}}return {{end}}{{.Cmd}}
 		}{{end}}
 	`)
-	tpl := template.Must(template.New("out").Parse(outTpl))
+	if withHook {
+		outTpl = hookTpl
+	}
+	tpl := template.New("out").Funcs(template.FuncMap{
+		"join": func(l []string) string { return strings.Join(l, ", ") },
+	})
+	tpl = template.Must(tpl.Parse(outTpl))
 	tplVars := struct {
 		Pkg     string
 		Imports []string
@@ -111,12 +125,74 @@ func main() {
 	requireNoErr(err)
 }
 
+// hookTpl generates methods that route calls to a wrapped implementation
+// through a hook. The struct, its call method, and file wrappers are expected
+// to be declared by the target package.
+var hookTpl = heredoc.Doc(`
+	package {{.Pkg}}
+
+	{{if .Imports}}
+	import ({{range .Imports}}
+		"{{.}}"{{end}}
+	)
+	{{end}}
+
+	// errResults maps ops to their number of results, for ops that return an
+	// error as their last result.
+	var errResults = map[string]int{ {{range .Funcs}}{{if .ErrResult}}
+		"{{.Name}}": {{len .Results}},{{end}}{{end}}
+	}
+
+	{{range .Funcs}}
+	{{.Doc}}func (h {{$.Osa}}) {{.Sig}} {
+		call := Call{
+			Op: "{{.Name}}",{{if .Paths}}
+			Paths: []string{ {{join .Paths}} },{{end}}{{if .Params}}
+			Args: []interface{}{ {{range .Params}}{{.Var}}, {{end}} },{{end}}
+		}
+		{{if .Results}}res := {{end}}h.call(call, func(c Call) []interface{} {
+			{{range $i, $p := .Params}}a{{$i}}, _ := c.Args[{{$i}}].({{$p.Type}})
+			{{end}}{{if .Results}}{{range $i, $r := .Results}}{{if $i}}, {{end}}r{{$i}}{{end}} := {{end -}}
+			h.o.{{.Name}}({{range $i, $p := .Params}}{{if $i}}, {{end}}a{{$i}}{{end}})
+			return {{if .Results}}[]interface{}{ {{range $i, $r := .Results}}r{{$i}}, {{end}} }{{else}}nil{{end}}
+		}){{range $i, $r := .Results}}
+		r{{$i}}, _ := res[{{$i}}].({{$r.Type}}){{end}}{{if .Wrap}}
+		return h.{{.Wrap}}(call, {{range $i, $r := .Results}}{{if $i}}, {{end}}r{{$i}}{{end}}){{else if .Results}}
+		return {{range $i, $r := .Results}}{{if $i}}, {{end}}r{{$i}}{{end}}{{end}}
+	}{{end}}
+`)
+
+// pathParams lists names of params that denote file paths.
+var pathParams = strset.New(
+	"name", "path", "dir", "oldpath", "newpath", "oldname", "newname",
+)
+
+// resultWrappers maps result types to hook wrapper methods, which route calls
+// on results through the hook as well.
+var resultWrappers = map[string]string{
+	"File":      "wrapFile",
+	"fs.File":   "wrapFSFile",
+	"io.Reader": "wrapReader",
+	"io.Writer": "wrapWriter",
+}
+
 type methodTplVars struct {
 	Doc     string
 	Name    string
 	Sig     string
 	Cmd     string
 	Returns bool
+
+	Params    []fieldTplVars
+	Results   []fieldTplVars
+	Paths     []string
+	Wrap      string
+	ErrResult bool
+}
+
+type fieldTplVars struct {
+	Var  string
+	Type string
 }
 
 type method struct {
@@ -286,10 +362,75 @@ func (p osaParser) fmtMethods(methods []method) []methodTplVars {
 			Cmd:     cmd,
 			Returns: m.ft.Results != nil,
 		}
+		if withHook {
+			p.fmtHookVars(&ms[i], m)
+		}
+		if withCtx {
+			ms[i].Doc = fmt.Sprintf(
+				"// %sContext is like %s but uses the OS abstraction of ctx.\n",
+				m.name, m.name,
+			)
+			ms[i].Name = m.name + "Context"
+			ms[i].Sig = p.fmtCtxSig(m)
+		}
 	}
 	return ms
 }
 
+// fmtHookVars sets template vars of hooked wrapper methods.
+func (p osaParser) fmtHookVars(vars *methodTplVars, m method) {
+	vars.Params = p.fmtFields(m.file.src, m.ft.Params)
+	vars.Results = p.fmtFields(m.file.src, m.ft.Results)
+	for _, param := range vars.Params {
+		if pathParams.Has(param.Var) && param.Type == "string" {
+			vars.Paths = append(vars.Paths, param.Var)
+		}
+	}
+	if n := len(vars.Results); n > 0 {
+		vars.Wrap = resultWrappers[vars.Results[0].Type]
+		vars.ErrResult = vars.Results[n-1].Type == "error"
+	}
+}
+
+// fmtFields lists each field of fl, one per name.
+func (p osaParser) fmtFields(src []byte, fl *ast.FieldList) []fieldTplVars {
+	if fl == nil {
+		return nil
+	}
+	fields := make([]fieldTplVars, 0, fl.NumFields())
+	for _, f := range fl.List {
+		from := p.fset.Position(f.Type.Pos()).Offset
+		to := p.fset.Position(f.Type.End()).Offset
+		typ := string(src[from:to])
+		if len(f.Names) == 0 {
+			fields = append(fields, fieldTplVars{Type: typ})
+		}
+		for _, name := range f.Names {
+			fields = append(fields, fieldTplVars{Var: name.Name, Type: typ})
+		}
+	}
+	return fields
+}
+
+// fmtCtxSig formats the signature of a method with a leading context param.
+func (p osaParser) fmtCtxSig(m method) string {
+	src := m.file.src
+	params := m.ft.Params
+	from := p.fset.Position(params.Opening).Offset + 1
+	to := p.fset.Position(params.Closing).Offset
+	sig := m.name + "Context(ctx context.Context"
+	if args := string(src[from:to]); args != "" {
+		sig += ", " + args
+	}
+	sig += ")"
+	if res := m.ft.Results; res != nil {
+		from := p.fset.Position(res.Pos()).Offset
+		to := p.fset.Position(res.End()).Offset
+		sig += " " + string(src[from:to])
+	}
+	return sig
+}
+
 func (p osaParser) extractSrcSlice(src []byte, field *ast.Field) []byte {
 	from := p.fset.Position(field.Pos()).Offset
 	to := p.fset.Position(field.End()).Offset
@@ -310,24 +451,37 @@ func wasGenerated(src []byte) bool {
 func collectImports(methods []method) *strset.Set {
 	imports := strset.New()
 	for _, method := range methods {
-		res := method.ft.Results
-		if res == nil {
-			continue
-		}
-		for _, rf := range method.ft.Results.List {
-			rt := rf.Type
-			if rse, ok := rt.(*ast.SelectorExpr); ok {
-				pkg := rse.X.(*ast.Ident).Name
-				if pkgPth, ok := knownImports[pkg]; ok {
-					pkg = pkgPth
+		for _, fl := range []*ast.FieldList{method.ft.Params, method.ft.Results} {
+			if fl == nil {
+				continue
+			}
+			for _, f := range fl.List {
+				if pkg, ok := typeImport(f.Type); ok {
+					imports.Add(pkg)
 				}
-				imports.Add(pkg)
 			}
 		}
 	}
 	return imports
 }
 
+// typeImport returns the import path of a package-qualified type.
+func typeImport(t ast.Expr) (string, bool) {
+	switch t := t.(type) {
+	case *ast.SelectorExpr:
+		pkg := t.X.(*ast.Ident).Name
+		if pkgPth, ok := knownImports[pkg]; ok {
+			pkg = pkgPth
+		}
+		return pkg, true
+	case *ast.ArrayType:
+		return typeImport(t.Elt)
+	case *ast.StarExpr:
+		return typeImport(t.X)
+	}
+	return "", false
+}
+
 func fmtImports(imports *strset.Set) []string {
 	l := imports.List()
 	sort.Strings(l)
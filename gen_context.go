@@ -0,0 +1,193 @@
+// Code generated by osa/gen. DO NOT EDIT.
+package osa
+
+import (
+	"context"
+	"io/fs"
+	"time"
+)
+
+// OpenContext is like Open but uses the OS abstraction of ctx.
+func OpenContext(ctx context.Context, name string) (fs.File, error) {
+	return FromContext(ctx).Open(name)
+}
+
+// OpenFileContext is like OpenFile but uses the OS abstraction of ctx.
+func OpenFileContext(ctx context.Context, name string, flag int, perm FileMode) (File, error) {
+	return FromContext(ctx).OpenFile(name, flag, perm)
+}
+
+// CreateContext is like Create but uses the OS abstraction of ctx.
+func CreateContext(ctx context.Context, name string) (File, error) {
+	return FromContext(ctx).Create(name)
+}
+
+// StatContext is like Stat but uses the OS abstraction of ctx.
+func StatContext(ctx context.Context, name string) (FileInfo, error) {
+	return FromContext(ctx).Stat(name)
+}
+
+// LstatContext is like Lstat but uses the OS abstraction of ctx.
+func LstatContext(ctx context.Context, name string) (FileInfo, error) {
+	return FromContext(ctx).Lstat(name)
+}
+
+// IsExistContext is like IsExist but uses the OS abstraction of ctx.
+func IsExistContext(ctx context.Context, err error) bool {
+	return FromContext(ctx).IsExist(err)
+}
+
+// IsNotExistContext is like IsNotExist but uses the OS abstraction of ctx.
+func IsNotExistContext(ctx context.Context, err error) bool {
+	return FromContext(ctx).IsNotExist(err)
+}
+
+// IsPermissionContext is like IsPermission but uses the OS abstraction of ctx.
+func IsPermissionContext(ctx context.Context, err error) bool {
+	return FromContext(ctx).IsPermission(err)
+}
+
+// PathSeparatorContext is like PathSeparator but uses the OS abstraction of ctx.
+func PathSeparatorContext(ctx context.Context) uint8 {
+	return FromContext(ctx).PathSeparator()
+}
+
+// IsPathSeparatorContext is like IsPathSeparator but uses the OS abstraction of ctx.
+func IsPathSeparatorContext(ctx context.Context, c uint8) bool {
+	return FromContext(ctx).IsPathSeparator(c)
+}
+
+// MkdirContext is like Mkdir but uses the OS abstraction of ctx.
+func MkdirContext(ctx context.Context, name string, perm FileMode) error {
+	return FromContext(ctx).Mkdir(name, perm)
+}
+
+// MkdirAllContext is like MkdirAll but uses the OS abstraction of ctx.
+func MkdirAllContext(ctx context.Context, name string, perm FileMode) error {
+	return FromContext(ctx).MkdirAll(name, perm)
+}
+
+// MkdirTempContext is like MkdirTemp but uses the OS abstraction of ctx.
+func MkdirTempContext(ctx context.Context, dir, pattern string) (string, error) {
+	return FromContext(ctx).MkdirTemp(dir, pattern)
+}
+
+// ReadDirContext is like ReadDir but uses the OS abstraction of ctx.
+func ReadDirContext(ctx context.Context, name string) ([]DirEntry, error) {
+	return FromContext(ctx).ReadDir(name)
+}
+
+// WriteFileContext is like WriteFile but uses the OS abstraction of ctx.
+func WriteFileContext(ctx context.Context, name string, data []byte, perm FileMode) error {
+	return FromContext(ctx).WriteFile(name, data, perm)
+}
+
+// ReadFileContext is like ReadFile but uses the OS abstraction of ctx.
+func ReadFileContext(ctx context.Context, name string) ([]byte, error) {
+	return FromContext(ctx).ReadFile(name)
+}
+
+// RenameContext is like Rename but uses the OS abstraction of ctx.
+func RenameContext(ctx context.Context, oldpath, newpath string) error {
+	return FromContext(ctx).Rename(oldpath, newpath)
+}
+
+// RemoveContext is like Remove but uses the OS abstraction of ctx.
+func RemoveContext(ctx context.Context, name string) error {
+	return FromContext(ctx).Remove(name)
+}
+
+// RemoveAllContext is like RemoveAll but uses the OS abstraction of ctx.
+func RemoveAllContext(ctx context.Context, path string) error {
+	return FromContext(ctx).RemoveAll(path)
+}
+
+// LinkContext is like Link but uses the OS abstraction of ctx.
+func LinkContext(ctx context.Context, oldname, newname string) error {
+	return FromContext(ctx).Link(oldname, newname)
+}
+
+// SameFileContext is like SameFile but uses the OS abstraction of ctx.
+func SameFileContext(ctx context.Context, fi1, fi2 FileInfo) bool {
+	return FromContext(ctx).SameFile(fi1, fi2)
+}
+
+// SymlinkContext is like Symlink but uses the OS abstraction of ctx.
+func SymlinkContext(ctx context.Context, oldname, newname string) error {
+	return FromContext(ctx).Symlink(oldname, newname)
+}
+
+// ReadlinkContext is like Readlink but uses the OS abstraction of ctx.
+func ReadlinkContext(ctx context.Context, name string) (string, error) {
+	return FromContext(ctx).Readlink(name)
+}
+
+// ChmodContext is like Chmod but uses the OS abstraction of ctx.
+func ChmodContext(ctx context.Context, name string, mode FileMode) error {
+	return FromContext(ctx).Chmod(name, mode)
+}
+
+// ChtimesContext is like Chtimes but uses the OS abstraction of ctx.
+func ChtimesContext(ctx context.Context, name string, atime time.Time, mtime time.Time) error {
+	return FromContext(ctx).Chtimes(name, atime, mtime)
+}
+
+// GetwdContext is like Getwd but uses the OS abstraction of ctx.
+func GetwdContext(ctx context.Context) (dir string, err error) {
+	return FromContext(ctx).Getwd()
+}
+
+// ChdirContext is like Chdir but uses the OS abstraction of ctx.
+func ChdirContext(ctx context.Context, dir string) error {
+	return FromContext(ctx).Chdir(dir)
+}
+
+// UserCacheDirContext is like UserCacheDir but uses the OS abstraction of ctx.
+func UserCacheDirContext(ctx context.Context) (string, error) {
+	return FromContext(ctx).UserCacheDir()
+}
+
+// UserConfigDirContext is like UserConfigDir but uses the OS abstraction of ctx.
+func UserConfigDirContext(ctx context.Context) (string, error) {
+	return FromContext(ctx).UserConfigDir()
+}
+
+// UserHomeDirContext is like UserHomeDir but uses the OS abstraction of ctx.
+func UserHomeDirContext(ctx context.Context) (string, error) {
+	return FromContext(ctx).UserHomeDir()
+}
+
+// ExitContext is like Exit but uses the OS abstraction of ctx.
+func ExitContext(ctx context.Context, code int) {
+	FromContext(ctx).Exit(code)
+}
+
+// GetenvContext is like Getenv but uses the OS abstraction of ctx.
+func GetenvContext(ctx context.Context, key string) string {
+	return FromContext(ctx).Getenv(key)
+}
+
+// LookupEnvContext is like LookupEnv but uses the OS abstraction of ctx.
+func LookupEnvContext(ctx context.Context, key string) (string, bool) {
+	return FromContext(ctx).LookupEnv(key)
+}
+
+// SetenvContext is like Setenv but uses the OS abstraction of ctx.
+func SetenvContext(ctx context.Context, key, value string) error {
+	return FromContext(ctx).Setenv(key, value)
+}
+
+// UnsetenvContext is like Unsetenv but uses the OS abstraction of ctx.
+func UnsetenvContext(ctx context.Context, key string) error {
+	return FromContext(ctx).Unsetenv(key)
+}
+
+// EnvironContext is like Environ but uses the OS abstraction of ctx.
+func EnvironContext(ctx context.Context) []string {
+	return FromContext(ctx).Environ()
+}
+
+// ExpandEnvContext is like ExpandEnv but uses the OS abstraction of ctx.
+func ExpandEnvContext(ctx context.Context, s string) string {
+	return FromContext(ctx).ExpandEnv(s)
+}
@@ -3,108 +3,199 @@ package osa
 
 import (
 	"io/fs"
+	"time"
 )
 
 // Open opens the named file.
 func Open(name string) (fs.File, error) {
-	return osa.Open(name)
+	return Current().Open(name)
 }
 
-// Lstat returns a FileInfo describing the named file.
+// OpenFile opens the named file with the specified flag (O_RDONLY etc.).
+func OpenFile(name string, flag int, perm FileMode) (File, error) {
+	return Current().OpenFile(name, flag, perm)
+}
+
+// Create creates or truncates the named file.
+func Create(name string) (File, error) {
+	return Current().Create(name)
+}
+
+// Stat returns a FileInfo describing the named file.
 func Stat(name string) (FileInfo, error) {
-	return osa.Stat(name)
+	return Current().Stat(name)
+}
+
+// Lstat returns a FileInfo describing the named file. If the file is a
+// symbolic link, the returned FileInfo describes the symbolic link.
+func Lstat(name string) (FileInfo, error) {
+	return Current().Lstat(name)
 }
 
 // IsExist returns a boolean indicating whether the error is known to report
 // that a file or directory already exists.
 func IsExist(err error) bool {
-	return osa.IsExist(err)
+	return Current().IsExist(err)
 }
 
 // IsNotExist returns a boolean indicating whether the error is known to
 // report that a file or directory does not exist.
 func IsNotExist(err error) bool {
-	return osa.IsNotExist(err)
+	return Current().IsNotExist(err)
+}
+
+// IsPermission returns a boolean indicating whether the error is known to
+// report that permission is denied.
+func IsPermission(err error) bool {
+	return Current().IsPermission(err)
 }
 
 // PathSeparator returns the directory separator character.
 func PathSeparator() uint8 {
-	return osa.PathSeparator()
+	return Current().PathSeparator()
 }
 
 // IsPathSeparator reports whether c is a directory separator character.
 func IsPathSeparator(c uint8) bool {
-	return osa.IsPathSeparator(c)
+	return Current().IsPathSeparator(c)
 }
 
 // Mkdir creates a new directory.
 func Mkdir(name string, perm FileMode) error {
-	return osa.Mkdir(name, perm)
+	return Current().Mkdir(name, perm)
 }
 
 // MkdirAll creates a directory named path, along with any necessary parents.
 func MkdirAll(name string, perm FileMode) error {
-	return osa.MkdirAll(name, perm)
+	return Current().MkdirAll(name, perm)
 }
 
 // MkdirTemp creates a new temporary directory in the directory dir and
 // returns the pathname of the new directory.
 func MkdirTemp(dir, pattern string) (string, error) {
-	return osa.MkdirTemp(dir, pattern)
+	return Current().MkdirTemp(dir, pattern)
 }
 
 // ReadDir reads the named directory and returns all its directory entries
 // sorted by filename.
 func ReadDir(name string) ([]DirEntry, error) {
-	return osa.ReadDir(name)
+	return Current().ReadDir(name)
 }
 
 // WriteFile writes data to the named file, creating it if necessary.
 func WriteFile(name string, data []byte, perm FileMode) error {
-	return osa.WriteFile(name, data, perm)
+	return Current().WriteFile(name, data, perm)
 }
 
 // ReadFile reads the named file and returns the contents.
 func ReadFile(name string) ([]byte, error) {
-	return osa.ReadFile(name)
+	return Current().ReadFile(name)
 }
 
 // Rename renames (moves) oldpath to newpath.
 func Rename(oldpath, newpath string) error {
-	return osa.Rename(oldpath, newpath)
+	return Current().Rename(oldpath, newpath)
 }
 
 // Remove removes the named file or empty directory.
 func Remove(name string) error {
-	return osa.Remove(name)
+	return Current().Remove(name)
 }
 
 // RemoveAll removes path and any children it contains
 func RemoveAll(path string) error {
-	return osa.RemoveAll(path)
+	return Current().RemoveAll(path)
+}
+
+// Link creates newname as a hard link to the oldname file.
+func Link(oldname, newname string) error {
+	return Current().Link(oldname, newname)
+}
+
+// SameFile reports whether fi1 and fi2 describe the same file.
+func SameFile(fi1, fi2 FileInfo) bool {
+	return Current().SameFile(fi1, fi2)
+}
+
+// Symlink creates newname as a symbolic link to oldname.
+func Symlink(oldname, newname string) error {
+	return Current().Symlink(oldname, newname)
+}
+
+// Readlink returns the destination of the named symbolic link.
+func Readlink(name string) (string, error) {
+	return Current().Readlink(name)
+}
+
+// Chmod changes the mode of the named file to mode.
+func Chmod(name string, mode FileMode) error {
+	return Current().Chmod(name, mode)
+}
+
+// Chtimes changes the access and modification times of the named file.
+func Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return Current().Chtimes(name, atime, mtime)
 }
 
 // Getwd returns a rooted path name corresponding to the current directory.
 func Getwd() (dir string, err error) {
-	return osa.Getwd()
+	return Current().Getwd()
+}
+
+// Chdir changes the current working directory to the named directory.
+func Chdir(dir string) error {
+	return Current().Chdir(dir)
 }
 
 // UserCacheDir returns the default directory to use for cached data.
 func UserCacheDir() (string, error) {
-	return osa.UserCacheDir()
+	return Current().UserCacheDir()
 }
 
 // UserConfigDir returns the default directory to use for configuration data.
 func UserConfigDir() (string, error) {
-	return osa.UserConfigDir()
+	return Current().UserConfigDir()
 }
 
 // UserHomeDir returns the current user's home directory.
 func UserHomeDir() (string, error) {
-	return osa.UserHomeDir()
+	return Current().UserHomeDir()
 }
 
 // Exit causes the current program to exit with the given status code.
 func Exit(code int) {
-	osa.Exit(code)
+	Current().Exit(code)
+}
+
+// Getenv retrieves the value of the environment variable named by the key.
+func Getenv(key string) string {
+	return Current().Getenv(key)
+}
+
+// LookupEnv retrieves the value of the environment variable named by the
+// key and reports whether the variable is present.
+func LookupEnv(key string) (string, bool) {
+	return Current().LookupEnv(key)
+}
+
+// Setenv sets the value of the environment variable named by the key.
+func Setenv(key, value string) error {
+	return Current().Setenv(key, value)
+}
+
+// Unsetenv unsets a single environment variable.
+func Unsetenv(key string) error {
+	return Current().Unsetenv(key)
+}
+
+// Environ returns a copy of strings representing the environment, in the
+// form "key=value".
+func Environ() []string {
+	return Current().Environ()
+}
+
+// ExpandEnv replaces ${var} or $var in the string according to the values
+// of the current environment variables.
+func ExpandEnv(s string) string {
+	return Current().ExpandEnv(s)
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/echocrow/osa"
 	"io"
 	"io/fs"
+	"time"
 )
 
 type gbl struct{}
@@ -14,11 +15,27 @@ func (gbl) Open(name string) (fs.File, error) {
 	return osa.Open(name)
 }
 
-// Lstat returns a FileInfo describing the named file.
+// OpenFile opens the named file with the specified flag (O_RDONLY etc.).
+func (gbl) OpenFile(name string, flag int, perm FileMode) (File, error) {
+	return osa.OpenFile(name, flag, perm)
+}
+
+// Create creates or truncates the named file.
+func (gbl) Create(name string) (File, error) {
+	return osa.Create(name)
+}
+
+// Stat returns a FileInfo describing the named file.
 func (gbl) Stat(name string) (FileInfo, error) {
 	return osa.Stat(name)
 }
 
+// Lstat returns a FileInfo describing the named file. If the file is a
+// symbolic link, the returned FileInfo describes the symbolic link.
+func (gbl) Lstat(name string) (FileInfo, error) {
+	return osa.Lstat(name)
+}
+
 // IsExist returns a boolean indicating whether the error is known to report
 // that a file or directory already exists.
 func (gbl) IsExist(err error) bool {
@@ -31,6 +48,12 @@ func (gbl) IsNotExist(err error) bool {
 	return osa.IsNotExist(err)
 }
 
+// IsPermission returns a boolean indicating whether the error is known to
+// report that permission is denied.
+func (gbl) IsPermission(err error) bool {
+	return osa.IsPermission(err)
+}
+
 // PathSeparator returns the directory separator character.
 func (gbl) PathSeparator() uint8 {
 	return osa.PathSeparator()
@@ -88,11 +111,46 @@ func (gbl) RemoveAll(path string) error {
 	return osa.RemoveAll(path)
 }
 
+// Link creates newname as a hard link to the oldname file.
+func (gbl) Link(oldname, newname string) error {
+	return osa.Link(oldname, newname)
+}
+
+// SameFile reports whether fi1 and fi2 describe the same file.
+func (gbl) SameFile(fi1, fi2 FileInfo) bool {
+	return osa.SameFile(fi1, fi2)
+}
+
+// Symlink creates newname as a symbolic link to oldname.
+func (gbl) Symlink(oldname, newname string) error {
+	return osa.Symlink(oldname, newname)
+}
+
+// Readlink returns the destination of the named symbolic link.
+func (gbl) Readlink(name string) (string, error) {
+	return osa.Readlink(name)
+}
+
+// Chmod changes the mode of the named file to mode.
+func (gbl) Chmod(name string, mode FileMode) error {
+	return osa.Chmod(name, mode)
+}
+
+// Chtimes changes the access and modification times of the named file.
+func (gbl) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return osa.Chtimes(name, atime, mtime)
+}
+
 // Getwd returns a rooted path name corresponding to the current directory.
 func (gbl) Getwd() (dir string, err error) {
 	return osa.Getwd()
 }
 
+// Chdir changes the current working directory to the named directory.
+func (gbl) Chdir(dir string) error {
+	return osa.Chdir(dir)
+}
+
 // UserCacheDir returns the default directory to use for cached data.
 func (gbl) UserCacheDir() (string, error) {
 	return osa.UserCacheDir()
@@ -113,6 +171,39 @@ func (gbl) Exit(code int) {
 	osa.Exit(code)
 }
 
+// Getenv retrieves the value of the environment variable named by the key.
+func (gbl) Getenv(key string) string {
+	return osa.Getenv(key)
+}
+
+// LookupEnv retrieves the value of the environment variable named by the
+// key and reports whether the variable is present.
+func (gbl) LookupEnv(key string) (string, bool) {
+	return osa.LookupEnv(key)
+}
+
+// Setenv sets the value of the environment variable named by the key.
+func (gbl) Setenv(key, value string) error {
+	return osa.Setenv(key, value)
+}
+
+// Unsetenv unsets a single environment variable.
+func (gbl) Unsetenv(key string) error {
+	return osa.Unsetenv(key)
+}
+
+// Environ returns a copy of strings representing the environment, in the
+// form "key=value".
+func (gbl) Environ() []string {
+	return osa.Environ()
+}
+
+// ExpandEnv replaces ${var} or $var in the string according to the values
+// of the current environment variables.
+func (gbl) ExpandEnv(s string) string {
+	return osa.ExpandEnv(s)
+}
+
 // Stdin returns IO reader for Stdin.
 func (gbl) Stdin() io.Reader {
 	return osa.Stdin
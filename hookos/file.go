@@ -0,0 +1,142 @@
+package hookos
+
+import "io/fs"
+
+// fileErrResults maps file ops to their number of results, for ops that
+// return an error as their last result.
+var fileErrResults = map[string]int{
+	"File.Read":     2,
+	"File.ReadAt":   2,
+	"File.Write":    2,
+	"File.WriteAt":  2,
+	"File.Seek":     2,
+	"File.Truncate": 1,
+	"File.Sync":     1,
+	"File.Close":    1,
+	"File.Stat":     2,
+	"File.ReadDir":  2,
+}
+
+// file wraps a File, routing its method calls through a hook.
+type file struct {
+	h    hookos
+	f    File
+	name string
+}
+
+func (f *file) call(op string, next Next, args ...interface{}) []interface{} {
+	c := Call{
+		Op:    "File." + op,
+		Paths: []string{f.name},
+		Args:  args,
+	}
+	return f.h.call(c, next)
+}
+
+func (f *file) Read(b []byte) (int, error) {
+	res := f.call("Read", func(c Call) []interface{} {
+		a0, _ := c.Args[0].([]byte)
+		r0, r1 := f.f.Read(a0)
+		return []interface{}{r0, r1}
+	}, b)
+	r0, _ := res[0].(int)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+func (f *file) ReadAt(b []byte, off int64) (int, error) {
+	res := f.call("ReadAt", func(c Call) []interface{} {
+		a0, _ := c.Args[0].([]byte)
+		a1, _ := c.Args[1].(int64)
+		r0, r1 := f.f.ReadAt(a0, a1)
+		return []interface{}{r0, r1}
+	}, b, off)
+	r0, _ := res[0].(int)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+func (f *file) Write(b []byte) (int, error) {
+	res := f.call("Write", func(c Call) []interface{} {
+		a0, _ := c.Args[0].([]byte)
+		r0, r1 := f.f.Write(a0)
+		return []interface{}{r0, r1}
+	}, b)
+	r0, _ := res[0].(int)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+func (f *file) WriteAt(b []byte, off int64) (int, error) {
+	res := f.call("WriteAt", func(c Call) []interface{} {
+		a0, _ := c.Args[0].([]byte)
+		a1, _ := c.Args[1].(int64)
+		r0, r1 := f.f.WriteAt(a0, a1)
+		return []interface{}{r0, r1}
+	}, b, off)
+	r0, _ := res[0].(int)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	res := f.call("Seek", func(c Call) []interface{} {
+		a0, _ := c.Args[0].(int64)
+		a1, _ := c.Args[1].(int)
+		r0, r1 := f.f.Seek(a0, a1)
+		return []interface{}{r0, r1}
+	}, offset, whence)
+	r0, _ := res[0].(int64)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+func (f *file) Truncate(size int64) error {
+	res := f.call("Truncate", func(c Call) []interface{} {
+		a0, _ := c.Args[0].(int64)
+		return []interface{}{f.f.Truncate(a0)}
+	}, size)
+	r0, _ := res[0].(error)
+	return r0
+}
+
+func (f *file) Sync() error {
+	res := f.call("Sync", func(c Call) []interface{} {
+		return []interface{}{f.f.Sync()}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+func (f *file) Close() error {
+	res := f.call("Close", func(c Call) []interface{} {
+		return []interface{}{f.f.Close()}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+func (f *file) Name() string {
+	return f.f.Name()
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	res := f.call("Stat", func(c Call) []interface{} {
+		r0, r1 := f.f.Stat()
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(fs.FileInfo)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+func (f *file) ReadDir(n int) ([]fs.DirEntry, error) {
+	res := f.call("ReadDir", func(c Call) []interface{} {
+		a0, _ := c.Args[0].(int)
+		r0, r1 := f.f.ReadDir(a0)
+		return []interface{}{r0, r1}
+	}, n)
+	r0, _ := res[0].([]fs.DirEntry)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
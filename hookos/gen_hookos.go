@@ -0,0 +1,677 @@
+// Code generated by osa/gen. DO NOT EDIT.
+package hookos
+
+import (
+	"io"
+	"io/fs"
+	"time"
+)
+
+// errResults maps ops to their number of results, for ops that return an
+// error as their last result.
+var errResults = map[string]int{
+	"Open":          2,
+	"OpenFile":      2,
+	"Create":        2,
+	"Stat":          2,
+	"Lstat":         2,
+	"Mkdir":         1,
+	"MkdirAll":      1,
+	"MkdirTemp":     2,
+	"ReadDir":       2,
+	"WriteFile":     1,
+	"ReadFile":      2,
+	"Rename":        1,
+	"Remove":        1,
+	"RemoveAll":     1,
+	"Link":          1,
+	"Symlink":       1,
+	"Readlink":      2,
+	"Chmod":         1,
+	"Chtimes":       1,
+	"Getwd":         2,
+	"Chdir":         1,
+	"UserCacheDir":  2,
+	"UserConfigDir": 2,
+	"UserHomeDir":   2,
+	"Setenv":        1,
+	"Unsetenv":      1,
+}
+
+// Open opens the named file.
+func (h hookos) Open(name string) (fs.File, error) {
+	call := Call{
+		Op:    "Open",
+		Paths: []string{name},
+		Args:  []interface{}{name},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		r0, r1 := h.o.Open(a0)
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(fs.File)
+	r1, _ := res[1].(error)
+	return h.wrapFSFile(call, r0, r1)
+}
+
+// OpenFile opens the named file with the specified flag (O_RDONLY etc.).
+func (h hookos) OpenFile(name string, flag int, perm FileMode) (File, error) {
+	call := Call{
+		Op:    "OpenFile",
+		Paths: []string{name},
+		Args:  []interface{}{name, flag, perm},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		a1, _ := c.Args[1].(int)
+		a2, _ := c.Args[2].(FileMode)
+		r0, r1 := h.o.OpenFile(a0, a1, a2)
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(File)
+	r1, _ := res[1].(error)
+	return h.wrapFile(call, r0, r1)
+}
+
+// Create creates or truncates the named file.
+func (h hookos) Create(name string) (File, error) {
+	call := Call{
+		Op:    "Create",
+		Paths: []string{name},
+		Args:  []interface{}{name},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		r0, r1 := h.o.Create(a0)
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(File)
+	r1, _ := res[1].(error)
+	return h.wrapFile(call, r0, r1)
+}
+
+// Stat returns a FileInfo describing the named file.
+func (h hookos) Stat(name string) (FileInfo, error) {
+	call := Call{
+		Op:    "Stat",
+		Paths: []string{name},
+		Args:  []interface{}{name},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		r0, r1 := h.o.Stat(a0)
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(FileInfo)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+// Lstat returns a FileInfo describing the named file. If the file is a
+// symbolic link, the returned FileInfo describes the symbolic link.
+func (h hookos) Lstat(name string) (FileInfo, error) {
+	call := Call{
+		Op:    "Lstat",
+		Paths: []string{name},
+		Args:  []interface{}{name},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		r0, r1 := h.o.Lstat(a0)
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(FileInfo)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+// IsExist returns a boolean indicating whether the error is known to report
+// that a file or directory already exists.
+func (h hookos) IsExist(err error) bool {
+	call := Call{
+		Op:   "IsExist",
+		Args: []interface{}{err},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(error)
+		r0 := h.o.IsExist(a0)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(bool)
+	return r0
+}
+
+// IsNotExist returns a boolean indicating whether the error is known to
+// report that a file or directory does not exist.
+func (h hookos) IsNotExist(err error) bool {
+	call := Call{
+		Op:   "IsNotExist",
+		Args: []interface{}{err},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(error)
+		r0 := h.o.IsNotExist(a0)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(bool)
+	return r0
+}
+
+// IsPermission returns a boolean indicating whether the error is known to
+// report that permission is denied.
+func (h hookos) IsPermission(err error) bool {
+	call := Call{
+		Op:   "IsPermission",
+		Args: []interface{}{err},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(error)
+		r0 := h.o.IsPermission(a0)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(bool)
+	return r0
+}
+
+// PathSeparator returns the directory separator character.
+func (h hookos) PathSeparator() uint8 {
+	call := Call{
+		Op: "PathSeparator",
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		r0 := h.o.PathSeparator()
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(uint8)
+	return r0
+}
+
+// IsPathSeparator reports whether c is a directory separator character.
+func (h hookos) IsPathSeparator(c uint8) bool {
+	call := Call{
+		Op:   "IsPathSeparator",
+		Args: []interface{}{c},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(uint8)
+		r0 := h.o.IsPathSeparator(a0)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(bool)
+	return r0
+}
+
+// Mkdir creates a new directory.
+func (h hookos) Mkdir(name string, perm FileMode) error {
+	call := Call{
+		Op:    "Mkdir",
+		Paths: []string{name},
+		Args:  []interface{}{name, perm},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		a1, _ := c.Args[1].(FileMode)
+		r0 := h.o.Mkdir(a0, a1)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+// MkdirAll creates a directory named path, along with any necessary parents.
+func (h hookos) MkdirAll(name string, perm FileMode) error {
+	call := Call{
+		Op:    "MkdirAll",
+		Paths: []string{name},
+		Args:  []interface{}{name, perm},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		a1, _ := c.Args[1].(FileMode)
+		r0 := h.o.MkdirAll(a0, a1)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+// MkdirTemp creates a new temporary directory in the directory dir and
+// returns the pathname of the new directory.
+func (h hookos) MkdirTemp(dir, pattern string) (string, error) {
+	call := Call{
+		Op:    "MkdirTemp",
+		Paths: []string{dir},
+		Args:  []interface{}{dir, pattern},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		a1, _ := c.Args[1].(string)
+		r0, r1 := h.o.MkdirTemp(a0, a1)
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(string)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+// ReadDir reads the named directory and returns all its directory entries
+// sorted by filename.
+func (h hookos) ReadDir(name string) ([]DirEntry, error) {
+	call := Call{
+		Op:    "ReadDir",
+		Paths: []string{name},
+		Args:  []interface{}{name},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		r0, r1 := h.o.ReadDir(a0)
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].([]DirEntry)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+// WriteFile writes data to the named file, creating it if necessary.
+func (h hookos) WriteFile(name string, data []byte, perm FileMode) error {
+	call := Call{
+		Op:    "WriteFile",
+		Paths: []string{name},
+		Args:  []interface{}{name, data, perm},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		a1, _ := c.Args[1].([]byte)
+		a2, _ := c.Args[2].(FileMode)
+		r0 := h.o.WriteFile(a0, a1, a2)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+// ReadFile reads the named file and returns the contents.
+func (h hookos) ReadFile(name string) ([]byte, error) {
+	call := Call{
+		Op:    "ReadFile",
+		Paths: []string{name},
+		Args:  []interface{}{name},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		r0, r1 := h.o.ReadFile(a0)
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].([]byte)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+// Rename renames (moves) oldpath to newpath.
+func (h hookos) Rename(oldpath, newpath string) error {
+	call := Call{
+		Op:    "Rename",
+		Paths: []string{oldpath, newpath},
+		Args:  []interface{}{oldpath, newpath},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		a1, _ := c.Args[1].(string)
+		r0 := h.o.Rename(a0, a1)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+// Remove removes the named file or empty directory.
+func (h hookos) Remove(name string) error {
+	call := Call{
+		Op:    "Remove",
+		Paths: []string{name},
+		Args:  []interface{}{name},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		r0 := h.o.Remove(a0)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+// RemoveAll removes path and any children it contains
+func (h hookos) RemoveAll(path string) error {
+	call := Call{
+		Op:    "RemoveAll",
+		Paths: []string{path},
+		Args:  []interface{}{path},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		r0 := h.o.RemoveAll(a0)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+// Link creates newname as a hard link to the oldname file.
+func (h hookos) Link(oldname, newname string) error {
+	call := Call{
+		Op:    "Link",
+		Paths: []string{oldname, newname},
+		Args:  []interface{}{oldname, newname},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		a1, _ := c.Args[1].(string)
+		r0 := h.o.Link(a0, a1)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+// SameFile reports whether fi1 and fi2 describe the same file.
+func (h hookos) SameFile(fi1, fi2 FileInfo) bool {
+	call := Call{
+		Op:   "SameFile",
+		Args: []interface{}{fi1, fi2},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(FileInfo)
+		a1, _ := c.Args[1].(FileInfo)
+		r0 := h.o.SameFile(a0, a1)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(bool)
+	return r0
+}
+
+// Symlink creates newname as a symbolic link to oldname.
+func (h hookos) Symlink(oldname, newname string) error {
+	call := Call{
+		Op:    "Symlink",
+		Paths: []string{oldname, newname},
+		Args:  []interface{}{oldname, newname},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		a1, _ := c.Args[1].(string)
+		r0 := h.o.Symlink(a0, a1)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+// Readlink returns the destination of the named symbolic link.
+func (h hookos) Readlink(name string) (string, error) {
+	call := Call{
+		Op:    "Readlink",
+		Paths: []string{name},
+		Args:  []interface{}{name},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		r0, r1 := h.o.Readlink(a0)
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(string)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+// Chmod changes the mode of the named file to mode.
+func (h hookos) Chmod(name string, mode FileMode) error {
+	call := Call{
+		Op:    "Chmod",
+		Paths: []string{name},
+		Args:  []interface{}{name, mode},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		a1, _ := c.Args[1].(FileMode)
+		r0 := h.o.Chmod(a0, a1)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+// Chtimes changes the access and modification times of the named file.
+func (h hookos) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	call := Call{
+		Op:    "Chtimes",
+		Paths: []string{name},
+		Args:  []interface{}{name, atime, mtime},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		a1, _ := c.Args[1].(time.Time)
+		a2, _ := c.Args[2].(time.Time)
+		r0 := h.o.Chtimes(a0, a1, a2)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+// Getwd returns a rooted path name corresponding to the current directory.
+func (h hookos) Getwd() (dir string, err error) {
+	call := Call{
+		Op: "Getwd",
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		r0, r1 := h.o.Getwd()
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(string)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+// Chdir changes the current working directory to the named directory.
+func (h hookos) Chdir(dir string) error {
+	call := Call{
+		Op:    "Chdir",
+		Paths: []string{dir},
+		Args:  []interface{}{dir},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		r0 := h.o.Chdir(a0)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+// UserCacheDir returns the default directory to use for cached data.
+func (h hookos) UserCacheDir() (string, error) {
+	call := Call{
+		Op: "UserCacheDir",
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		r0, r1 := h.o.UserCacheDir()
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(string)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+// UserConfigDir returns the default directory to use for configuration data.
+func (h hookos) UserConfigDir() (string, error) {
+	call := Call{
+		Op: "UserConfigDir",
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		r0, r1 := h.o.UserConfigDir()
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(string)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+// UserHomeDir returns the current user's home directory.
+func (h hookos) UserHomeDir() (string, error) {
+	call := Call{
+		Op: "UserHomeDir",
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		r0, r1 := h.o.UserHomeDir()
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(string)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+// Exit causes the current program to exit with the given status code.
+func (h hookos) Exit(code int) {
+	call := Call{
+		Op:   "Exit",
+		Args: []interface{}{code},
+	}
+	h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(int)
+		h.o.Exit(a0)
+		return nil
+	})
+}
+
+// Getenv retrieves the value of the environment variable named by the key.
+func (h hookos) Getenv(key string) string {
+	call := Call{
+		Op:   "Getenv",
+		Args: []interface{}{key},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		r0 := h.o.Getenv(a0)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(string)
+	return r0
+}
+
+// LookupEnv retrieves the value of the environment variable named by the
+// key and reports whether the variable is present.
+func (h hookos) LookupEnv(key string) (string, bool) {
+	call := Call{
+		Op:   "LookupEnv",
+		Args: []interface{}{key},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		r0, r1 := h.o.LookupEnv(a0)
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(string)
+	r1, _ := res[1].(bool)
+	return r0, r1
+}
+
+// Setenv sets the value of the environment variable named by the key.
+func (h hookos) Setenv(key, value string) error {
+	call := Call{
+		Op:   "Setenv",
+		Args: []interface{}{key, value},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		a1, _ := c.Args[1].(string)
+		r0 := h.o.Setenv(a0, a1)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+// Unsetenv unsets a single environment variable.
+func (h hookos) Unsetenv(key string) error {
+	call := Call{
+		Op:   "Unsetenv",
+		Args: []interface{}{key},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		r0 := h.o.Unsetenv(a0)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(error)
+	return r0
+}
+
+// Environ returns a copy of strings representing the environment, in the
+// form "key=value".
+func (h hookos) Environ() []string {
+	call := Call{
+		Op: "Environ",
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		r0 := h.o.Environ()
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].([]string)
+	return r0
+}
+
+// ExpandEnv replaces ${var} or $var in the string according to the values
+// of the current environment variables.
+func (h hookos) ExpandEnv(s string) string {
+	call := Call{
+		Op:   "ExpandEnv",
+		Args: []interface{}{s},
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		a0, _ := c.Args[0].(string)
+		r0 := h.o.ExpandEnv(a0)
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(string)
+	return r0
+}
+
+// Stdin returns IO reader for Stdin.
+func (h hookos) Stdin() io.Reader {
+	call := Call{
+		Op: "Stdin",
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		r0 := h.o.Stdin()
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(io.Reader)
+	return h.wrapReader(call, r0)
+}
+
+// Stdout returns IO writer for Stdout.
+func (h hookos) Stdout() io.Writer {
+	call := Call{
+		Op: "Stdout",
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		r0 := h.o.Stdout()
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(io.Writer)
+	return h.wrapWriter(call, r0)
+}
+
+// Stderr returns IO writer for Stderr.
+func (h hookos) Stderr() io.Writer {
+	call := Call{
+		Op: "Stderr",
+	}
+	res := h.call(call, func(c Call) []interface{} {
+		r0 := h.o.Stderr()
+		return []interface{}{r0}
+	})
+	r0, _ := res[0].(io.Writer)
+	return h.wrapWriter(call, r0)
+}
@@ -0,0 +1,116 @@
+// Package hookos provides an OS abstraction implementation that routes all
+// calls to another implementation through a hook.
+//
+// Hooks may inspect, alter, or replace calls, which allows building wrappers
+// that inject faults, record calls, or replay recorded results.
+package hookos
+
+import (
+	"io/fs"
+
+	"github.com/echocrow/osa"
+)
+
+// Call describes a single call of an OS abstraction method.
+type Call struct {
+	// Op is the name of the called method, e.g. "WriteFile". Methods called
+	// on files are prefixed with "File.", e.g. "File.Write", and methods
+	// called on stdio are prefixed with their stream, e.g. "Stdout.Write".
+	Op string
+	// Paths holds the file paths the call operates on, if any. For methods
+	// called on files, this holds the name the file was opened with.
+	Paths []string
+	// Args holds the arguments of the call.
+	Args []interface{}
+}
+
+// Next calls the wrapped implementation with the arguments of c and returns
+// its results.
+type Next func(c Call) []interface{}
+
+// Hook intercepts a call and returns its results.
+//
+// A hook may call next to invoke the wrapped implementation, optionally with
+// altered arguments, or return results of its own. Results must match the
+// results of the called method in number and order.
+type Hook func(c Call, next Next) []interface{}
+
+type File = osa.File
+type FileInfo = osa.FileInfo
+type FileMode = osa.FileMode
+type DirEntry = osa.DirEntry
+
+//go:generate go run ../gen -pkg=.. -name=hookos -hook
+type hookos struct {
+	o    osa.I
+	hook Hook
+}
+
+// New creates a new hookos instance that wraps o.
+//
+// Every call, including calls on opened files, is passed to hook.
+func New(o osa.I, hook Hook) hookos {
+	return hookos{o, hook}
+}
+
+func (h hookos) call(c Call, next Next) []interface{} {
+	if h.hook == nil {
+		return next(c)
+	}
+	return h.hook(c, next)
+}
+
+// Fail returns results for c that report err, with all other results set to
+// their zero values. It reports false if the method of c does not return an
+// error.
+func Fail(c Call, err error) ([]interface{}, bool) {
+	n := numErrResults(c.Op)
+	if n == 0 {
+		return nil, false
+	}
+	res := make([]interface{}, n)
+	res[n-1] = err
+	return res, true
+}
+
+// ReturnsError reports whether the method named op returns an error as its
+// last result.
+func ReturnsError(op string) bool {
+	return numErrResults(op) > 0
+}
+
+// numErrResults returns the number of results of the method named op, or zero
+// if the method does not return an error.
+func numErrResults(op string) int {
+	for _, m := range []map[string]int{
+		errResults,
+		fileErrResults,
+		stdioErrResults,
+	} {
+		if n, ok := m[op]; ok {
+			return n
+		}
+	}
+	return 0
+}
+
+// wrapFSFile wraps f so that calls on f are hooked as well. Files that do not
+// implement File are returned as is.
+func (h hookos) wrapFSFile(c Call, f fs.File, err error) (fs.File, error) {
+	if ff, ok := f.(File); ok {
+		return h.wrapFile(c, ff, err)
+	}
+	return f, err
+}
+
+// wrapFile wraps f so that calls on f are hooked as well.
+func (h hookos) wrapFile(c Call, f File, err error) (File, error) {
+	if f == nil || err != nil {
+		return f, err
+	}
+	var name string
+	if len(c.Paths) > 0 {
+		name = c.Paths[0]
+	}
+	return &file{h, f, name}, nil
+}
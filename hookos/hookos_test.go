@@ -0,0 +1,143 @@
+package hookos_test
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/echocrow/osa/hookos"
+	"github.com/echocrow/osa/oos"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/testosa"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHookOS(t *testing.T) {
+	o := hookos.New(oos.New(), nil)
+	testosa.AssertOrgOS(t, o)
+}
+
+func TestHookOSPassThrough(t *testing.T) {
+	v := vos.New()
+	var mu sync.Mutex
+	ops := make(map[string]int)
+	h := hookos.New(v, func(c hookos.Call, next hookos.Next) []interface{} {
+		mu.Lock()
+		ops[c.Op]++
+		mu.Unlock()
+		return next(c)
+	})
+
+	mkTempDir := func() string { return vos.MkTempDir(v) }
+	assertExit := func(t *testing.T) {
+		defer vos.CatchExit(func(got int) {
+			assert.Equal(t, 3, got)
+		})
+		h.Exit(3)
+		t.Fatal("should have exited")
+	}
+	getStdio := func() (in io.Writer, out, err io.Reader, reset func()) {
+		in, out, err = vos.GetStdio(v)
+		return
+	}
+	testosa.AssertOsa(t, h, mkTempDir, assertExit, getStdio)
+
+	for _, op := range []string{"OpenFile", "File.Write", "File.Close", "Rename", "Exit"} {
+		assert.NotZero(t, ops[op], op)
+	}
+}
+
+func TestHookOSCall(t *testing.T) {
+	v := vos.New()
+	file := testos.Join(vos.MkTempDir(v), "file")
+
+	var calls []hookos.Call
+	h := hookos.New(v, func(c hookos.Call, next hookos.Next) []interface{} {
+		calls = append(calls, c)
+		return next(c)
+	})
+
+	f, err := h.Create(file)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("data"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, h.Rename(file, file+".bak"))
+
+	want := []hookos.Call{
+		{Op: "Create", Paths: []string{file}, Args: []interface{}{file}},
+		{Op: "File.Write", Paths: []string{file}, Args: []interface{}{[]byte("data")}},
+		{Op: "File.Close", Paths: []string{file}},
+		{Op: "Rename", Paths: []string{file, file + ".bak"}, Args: []interface{}{file, file + ".bak"}},
+	}
+	assert.Equal(t, want, calls)
+}
+
+func TestHookOSAlterCall(t *testing.T) {
+	v := vos.New()
+	file := testos.Join(vos.MkTempDir(v), "file")
+	h := hookos.New(v, func(c hookos.Call, next hookos.Next) []interface{} {
+		if c.Op == "File.Write" {
+			b := c.Args[0].([]byte)
+			c.Args = []interface{}{b[:2]}
+		}
+		return next(c)
+	})
+
+	f, err := h.Create(file)
+	require.NoError(t, err)
+	n, err := f.Write([]byte("data"))
+	assert.Equal(t, 2, n)
+	assert.NoError(t, err)
+	require.NoError(t, f.Close())
+	testos.AssertFileData(t, v, file, "da")
+}
+
+func TestFail(t *testing.T) {
+	errFail := errors.New("fail")
+	h := hookos.New(vos.New(), func(c hookos.Call, next hookos.Next) []interface{} {
+		if res, ok := hookos.Fail(c, errFail); ok {
+			return res
+		}
+		return next(c)
+	})
+
+	f, err := h.Open("file")
+	assert.Nil(t, f)
+	assert.Same(t, errFail, err)
+	assert.Same(t, errFail, h.Mkdir("dir", 0755))
+	assert.Equal(t, "/", string(h.PathSeparator()))
+
+	_, ok := hookos.Fail(hookos.Call{Op: "Getenv"}, errFail)
+	assert.False(t, ok)
+	res, ok := hookos.Fail(hookos.Call{Op: "File.Write"}, errFail)
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{nil, errFail}, res)
+}
+
+func TestHookOSStdio(t *testing.T) {
+	v := vos.New()
+	var ops []string
+	h := hookos.New(v, func(c hookos.Call, next hookos.Next) []interface{} {
+		ops = append(ops, c.Op)
+		return next(c)
+	})
+
+	_, stdout, _ := vos.GetStdio(v)
+	testos.AssertStdWrite(t, h.Stdout(), stdout, "some message")
+	assert.Equal(t, []string{"Stdout", "Stdout.Write"}, ops)
+
+	ops = nil
+	stdin, _, _ := vos.GetStdio(v)
+	_, err := stdin.Write([]byte("input"))
+	require.NoError(t, err)
+	data, err := io.ReadAll(h.Stdin())
+	require.NoError(t, err)
+	assert.Equal(t, "input", string(data))
+	assert.Equal(t, []string{"Stdin", "Stdin.Read", "Stdin.Read"}, ops)
+	assert.True(t, hookos.ReturnsError("Stdout.Write"))
+	assert.False(t, hookos.ReturnsError("Stdout"))
+}
@@ -0,0 +1,65 @@
+package hookos
+
+import "io"
+
+// stdioErrResults maps stdio ops to their number of results, for ops that
+// return an error as their last result.
+var stdioErrResults = map[string]int{
+	"Stdin.Read":   2,
+	"Stdout.Write": 2,
+	"Stderr.Write": 2,
+}
+
+// reader wraps a stdio reader, routing its method calls through a hook.
+type reader struct {
+	h  hookos
+	op string
+	r  io.Reader
+}
+
+func (r reader) Read(b []byte) (int, error) {
+	c := Call{Op: r.op + ".Read", Args: []interface{}{b}}
+	res := r.h.call(c, func(c Call) []interface{} {
+		a0, _ := c.Args[0].([]byte)
+		r0, r1 := r.r.Read(a0)
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(int)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+// writer wraps a stdio writer, routing its method calls through a hook.
+type writer struct {
+	h  hookos
+	op string
+	w  io.Writer
+}
+
+func (w writer) Write(b []byte) (int, error) {
+	c := Call{Op: w.op + ".Write", Args: []interface{}{b}}
+	res := w.h.call(c, func(c Call) []interface{} {
+		a0, _ := c.Args[0].([]byte)
+		r0, r1 := w.w.Write(a0)
+		return []interface{}{r0, r1}
+	})
+	r0, _ := res[0].(int)
+	r1, _ := res[1].(error)
+	return r0, r1
+}
+
+// wrapReader wraps the stdio reader r so that calls on r are hooked as well.
+func (h hookos) wrapReader(c Call, r io.Reader) io.Reader {
+	if r == nil {
+		return nil
+	}
+	return reader{h, c.Op, r}
+}
+
+// wrapWriter wraps the stdio writer w so that calls on w are hooked as well.
+func (h hookos) wrapWriter(c Call, w io.Writer) io.Writer {
+	if w == nil {
+		return nil
+	}
+	return writer{h, c.Op, w}
+}
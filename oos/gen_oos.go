@@ -5,6 +5,7 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"time"
 )
 
 type oos struct{}
@@ -14,11 +15,27 @@ func (oos) Open(name string) (fs.File, error) {
 	return os.Open(name)
 }
 
-// Lstat returns a FileInfo describing the named file.
+// OpenFile opens the named file with the specified flag (O_RDONLY etc.).
+func (oos) OpenFile(name string, flag int, perm FileMode) (File, error) {
+	return OpenFile(name, flag, perm)
+}
+
+// Create creates or truncates the named file.
+func (oos) Create(name string) (File, error) {
+	return Create(name)
+}
+
+// Stat returns a FileInfo describing the named file.
 func (oos) Stat(name string) (FileInfo, error) {
 	return os.Stat(name)
 }
 
+// Lstat returns a FileInfo describing the named file. If the file is a
+// symbolic link, the returned FileInfo describes the symbolic link.
+func (oos) Lstat(name string) (FileInfo, error) {
+	return os.Lstat(name)
+}
+
 // IsExist returns a boolean indicating whether the error is known to report
 // that a file or directory already exists.
 func (oos) IsExist(err error) bool {
@@ -31,6 +48,12 @@ func (oos) IsNotExist(err error) bool {
 	return os.IsNotExist(err)
 }
 
+// IsPermission returns a boolean indicating whether the error is known to
+// report that permission is denied.
+func (oos) IsPermission(err error) bool {
+	return os.IsPermission(err)
+}
+
 // PathSeparator returns the directory separator character.
 func (oos) PathSeparator() uint8 {
 	return PathSeparator()
@@ -88,11 +111,46 @@ func (oos) RemoveAll(path string) error {
 	return os.RemoveAll(path)
 }
 
+// Link creates newname as a hard link to the oldname file.
+func (oos) Link(oldname, newname string) error {
+	return os.Link(oldname, newname)
+}
+
+// SameFile reports whether fi1 and fi2 describe the same file.
+func (oos) SameFile(fi1, fi2 FileInfo) bool {
+	return os.SameFile(fi1, fi2)
+}
+
+// Symlink creates newname as a symbolic link to oldname.
+func (oos) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, newname)
+}
+
+// Readlink returns the destination of the named symbolic link.
+func (oos) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+// Chmod changes the mode of the named file to mode.
+func (oos) Chmod(name string, mode FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+// Chtimes changes the access and modification times of the named file.
+func (oos) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
 // Getwd returns a rooted path name corresponding to the current directory.
 func (oos) Getwd() (dir string, err error) {
 	return os.Getwd()
 }
 
+// Chdir changes the current working directory to the named directory.
+func (oos) Chdir(dir string) error {
+	return os.Chdir(dir)
+}
+
 // UserCacheDir returns the default directory to use for cached data.
 func (oos) UserCacheDir() (string, error) {
 	return os.UserCacheDir()
@@ -113,6 +171,39 @@ func (oos) Exit(code int) {
 	os.Exit(code)
 }
 
+// Getenv retrieves the value of the environment variable named by the key.
+func (oos) Getenv(key string) string {
+	return os.Getenv(key)
+}
+
+// LookupEnv retrieves the value of the environment variable named by the
+// key and reports whether the variable is present.
+func (oos) LookupEnv(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// Setenv sets the value of the environment variable named by the key.
+func (oos) Setenv(key, value string) error {
+	return os.Setenv(key, value)
+}
+
+// Unsetenv unsets a single environment variable.
+func (oos) Unsetenv(key string) error {
+	return os.Unsetenv(key)
+}
+
+// Environ returns a copy of strings representing the environment, in the
+// form "key=value".
+func (oos) Environ() []string {
+	return os.Environ()
+}
+
+// ExpandEnv replaces ${var} or $var in the string according to the values
+// of the current environment variables.
+func (oos) ExpandEnv(s string) string {
+	return os.ExpandEnv(s)
+}
+
 // Stdin returns IO reader for Stdin.
 func (oos) Stdin() io.Reader {
 	return os.Stdin
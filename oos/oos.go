@@ -5,16 +5,51 @@
 // does not need to import or directly interact with this package.
 package oos
 
-import "os"
+import (
+	"io/fs"
+	"os"
+)
 
 //go:generate go run ../gen -pkg=.. -name=oos -call=os -import=os
 func New() oos {
 	return oos{}
 }
 
-type File = *os.File
+// File mirrors osa.File, which is satisfied by *os.File.
+type File = interface {
+	Read(b []byte) (n int, err error)
+	ReadAt(b []byte, off int64) (n int, err error)
+	Write(b []byte) (n int, err error)
+	WriteAt(b []byte, off int64) (n int, err error)
+	Seek(offset int64, whence int) (ret int64, err error)
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+	Name() string
+	Stat() (fs.FileInfo, error)
+	ReadDir(n int) ([]fs.DirEntry, error)
+}
+
 type FileInfo = os.FileInfo
 type FileMode = os.FileMode
 type DirEntry = os.DirEntry
 
 func PathSeparator() uint8 { return os.PathSeparator }
+
+// OpenFile calls os.OpenFile, returning a nil File on error.
+func OpenFile(name string, flag int, perm FileMode) (File, error) {
+	return fileOrNil(os.OpenFile(name, flag, perm))
+}
+
+// Create calls os.Create, returning a nil File on error.
+func Create(name string) (File, error) {
+	return fileOrNil(os.Create(name))
+}
+
+// fileOrNil avoids wrapping a nil *os.File in a non-nil File interface.
+func fileOrNil(f *os.File, err error) (File, error) {
+	if f == nil {
+		return nil, err
+	}
+	return f, err
+}
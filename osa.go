@@ -18,6 +18,9 @@ package osa
 
 import (
 	"io/fs"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/echocrow/osa/oos"
 )
@@ -26,14 +29,24 @@ import (
 type I interface {
 	// Open opens the named file.
 	Open(name string) (fs.File, error)
-	// Lstat returns a FileInfo describing the named file.
+	// OpenFile opens the named file with the specified flag (O_RDONLY etc.).
+	OpenFile(name string, flag int, perm FileMode) (File, error)
+	// Create creates or truncates the named file.
+	Create(name string) (File, error)
+	// Stat returns a FileInfo describing the named file.
 	Stat(name string) (FileInfo, error)
+	// Lstat returns a FileInfo describing the named file. If the file is a
+	// symbolic link, the returned FileInfo describes the symbolic link.
+	Lstat(name string) (FileInfo, error)
 	// IsExist returns a boolean indicating whether the error is known to report
 	// that a file or directory already exists.
 	IsExist(err error) bool
 	// IsNotExist returns a boolean indicating whether the error is known to
 	// report that a file or directory does not exist.
 	IsNotExist(err error) bool
+	// IsPermission returns a boolean indicating whether the error is known to
+	// report that permission is denied.
+	IsPermission(err error) bool
 	// PathSeparator returns the directory separator character.
 	PathSeparator() uint8
 	// IsPathSeparator reports whether c is a directory separator character.
@@ -58,8 +71,22 @@ type I interface {
 	Remove(name string) error
 	// RemoveAll removes path and any children it contains
 	RemoveAll(path string) error
+	// Link creates newname as a hard link to the oldname file.
+	Link(oldname, newname string) error
+	// SameFile reports whether fi1 and fi2 describe the same file.
+	SameFile(fi1, fi2 FileInfo) bool
+	// Symlink creates newname as a symbolic link to oldname.
+	Symlink(oldname, newname string) error
+	// Readlink returns the destination of the named symbolic link.
+	Readlink(name string) (string, error)
+	// Chmod changes the mode of the named file to mode.
+	Chmod(name string, mode FileMode) error
+	// Chtimes changes the access and modification times of the named file.
+	Chtimes(name string, atime time.Time, mtime time.Time) error
 	// Getwd returns a rooted path name corresponding to the current directory.
 	Getwd() (dir string, err error)
+	// Chdir changes the current working directory to the named directory.
+	Chdir(dir string) error
 	// UserCacheDir returns the default directory to use for cached data.
 	UserCacheDir() (string, error)
 	// UserConfigDir returns the default directory to use for configuration data.
@@ -68,6 +95,8 @@ type I interface {
 	UserHomeDir() (string, error)
 	// Exit causes the current program to exit with the given status code.
 	Exit(code int)
+	// Env reads and modifies environment variables.
+	Env
 	// Stdio returns IO readers and writers for Stdin, Stdout, and Stderr.
 	Stdio
 }
@@ -75,21 +104,63 @@ type I interface {
 // Default returns the standard OS abstraction implementation.
 func Default() I { return oos.New() }
 
-var osa I = Default()
+// base holds the default OS abstraction implementation, active when no
+// patches are applied.
+var base = &patch{Default()}
+
+// current holds the active OS abstraction implementation.
+var current atomic.Value
+
+// patches tracks active patches in the order they were applied.
+var (
+	patchesMu sync.Mutex
+	patches   []*patch
+)
+
+// patch wraps an OS abstraction implementation, allowing nil implementations
+// to be stored in current.
+type patch struct{ o I }
+
+func init() {
+	current.Store(base)
+}
 
 // Current returns the current OS abstraction implementation.
-func Current() I { return osa }
+func Current() I { return current.Load().(*patch).o }
 
-// Patch monkey-patches the OS abstraction and returns a restore function.
+// Patch monkey-patches the OS abstraction and returns a reset function.
 //
-// BUG(echocrow): Patch is not yet thread-safe.
+// Patches are tracked on a stack. Calling a reset function removes its patch
+// from the stack and activates the most recent remaining patch (or the
+// default implementation), regardless of the order resets are invoked in.
+// Calling a reset function more than once has no effect.
 //
-// BUG(echocrow): Calling Patch multiple times before resetting may result in
-// incomplete resets when reset funcs are not invoked in reverse order.
+// Patch is safe for concurrent use. Note however that patches apply
+// process-wide, so concurrent tests that patch the OS abstraction will
+// observe each other's patches.
 func Patch(o I) func() {
-	org := osa
-	osa = o
-	return func() { osa = org }
+	p := &patch{o}
+	patchesMu.Lock()
+	patches = append(patches, p)
+	current.Store(p)
+	patchesMu.Unlock()
+	return func() { unpatch(p) }
+}
+
+func unpatch(p *patch) {
+	patchesMu.Lock()
+	defer patchesMu.Unlock()
+	for i, q := range patches {
+		if q == p {
+			patches = append(patches[:i], patches[i+1:]...)
+			break
+		}
+	}
+	if n := len(patches); n > 0 {
+		current.Store(patches[n-1])
+	} else {
+		current.Store(base)
+	}
 }
 
-//go:generate go run ./gen -call=osa
+//go:generate go run ./gen -call=Current()
@@ -14,6 +14,7 @@ import (
 type FileInfo = osa.FileInfo
 type FileMode = osa.FileMode
 type DirEntry = osa.DirEntry
+type File = osa.File
 
 func TestGlobals(t *testing.T) {
 	g := gbl{}
@@ -2,12 +2,14 @@ package osa_test
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/echocrow/osa"
 	"github.com/echocrow/osa/oos"
 	"github.com/echocrow/osa/testosa"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCurrentDefault(t *testing.T) {
@@ -31,3 +33,41 @@ func TestCurrentAndPatch(t *testing.T) {
 		})
 	}
 }
+
+// patchOS is a distinguishable OS abstraction for patch tests.
+type patchOS struct{ osa.I }
+
+func TestPatchOutOfOrderReset(t *testing.T) {
+	org := osa.Current()
+	a, b, c := &patchOS{}, &patchOS{}, &patchOS{}
+
+	resetA := osa.Patch(a)
+	resetB := osa.Patch(b)
+	resetC := osa.Patch(c)
+	require.Same(t, c, osa.Current())
+
+	resetB()
+	assert.Same(t, c, osa.Current(), "keeps latest patch")
+	resetC()
+	assert.Same(t, a, osa.Current(), "restores remaining patch")
+	resetC()
+	assert.Same(t, a, osa.Current(), "ignores repeated reset")
+	resetA()
+	assert.Exactly(t, org, osa.Current(), "restores original")
+}
+
+func TestPatchConcurrent(t *testing.T) {
+	org := osa.Current()
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reset := osa.Patch(oos.New())
+			osa.Current()
+			reset()
+		}()
+	}
+	wg.Wait()
+	assert.Exactly(t, org, osa.Current())
+}
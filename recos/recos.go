@@ -0,0 +1,287 @@
+// Package recos provides an OS abstraction implementation that records all
+// calls to another implementation.
+//
+// Each call is recorded with its op, arguments, results, error, duration, and
+// goroutine. The trace is available as a slice of records, and can be written
+// as JSON Lines:
+//
+//	r := recos.New(vos.New())
+//	// ...
+//	if t.Failed() {
+//		r.WriteJSON(os.Stderr)
+//	}
+package recos
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/hookos"
+)
+
+// Record describes a recorded call.
+type Record struct {
+	// Op is the name of the called method, e.g. "WriteFile" or "File.Write".
+	// See hookos.Call.
+	Op string
+	// Paths holds the file paths the call operated on, if any.
+	Paths []string
+	// Wd is the working directory when the call started, if any of its paths
+	// is relative. Relative paths of methods of File are only relative to it
+	// if the working directory did not change since the file was opened.
+	Wd string
+	// Args holds the arguments of the call. Byte slices are copied after the
+	// call completed.
+	Args []interface{}
+	// Results holds the results of the call, excluding the error.
+	Results []interface{}
+	// Err is the error returned by the call, if any.
+	Err error
+	// Start is the time the call started at.
+	Start time.Time
+	// Duration is the duration of the call.
+	Duration time.Duration
+	// Goroutine is the ID of the goroutine that issued the call.
+	Goroutine uint64
+}
+
+// writeFlags are OpenFile flags that may modify the file system.
+const writeFlags = osa.O_WRONLY | osa.O_RDWR | osa.O_CREATE | osa.O_TRUNC
+
+// writeOps lists methods that modify the file system.
+var writeOps = map[string]bool{
+	"Create":        true,
+	"Mkdir":         true,
+	"MkdirAll":      true,
+	"MkdirTemp":     true,
+	"WriteFile":     true,
+	"Rename":        true,
+	"Remove":        true,
+	"RemoveAll":     true,
+	"Link":          true,
+	"Symlink":       true,
+	"Chmod":         true,
+	"Chtimes":       true,
+	"File.Write":    true,
+	"File.WriteAt":  true,
+	"File.Truncate": true,
+}
+
+// Written returns the paths a recorded call may have modified, such as paths of
+// written, created, or removed files. It returns nil for calls that do not
+// modify the file system.
+func (r Record) Written() []string {
+	switch r.Op {
+	case "OpenFile":
+		if flag, _ := r.Args[1].(int); flag&writeFlags == 0 {
+			return nil
+		}
+	case "MkdirTemp":
+		if dir, _ := r.Results[0].(string); r.Err == nil {
+			return []string{dir}
+		}
+	case "Link", "Symlink":
+		return r.Paths[1:]
+	}
+	if !writeOps[r.Op] && r.Op != "OpenFile" {
+		return nil
+	}
+	return r.Paths
+}
+
+// jsonRecord is the JSON representation of a Record.
+type jsonRecord struct {
+	Op        string        `json:"op"`
+	Paths     []string      `json:"paths,omitempty"`
+	Wd        string        `json:"wd,omitempty"`
+	Args      []interface{} `json:"args,omitempty"`
+	Results   []interface{} `json:"results,omitempty"`
+	Err       string        `json:"err,omitempty"`
+	Start     time.Time     `json:"start"`
+	Duration  time.Duration `json:"duration"`
+	Goroutine uint64        `json:"goroutine"`
+}
+
+// MarshalJSON encodes the record as JSON.
+//
+// Values that do not encode well are summarized: byte slices are encoded as
+// strings, errors as their messages, files by their names, and file infos and
+// directory entries by their names and modes.
+func (r Record) MarshalJSON() ([]byte, error) {
+	jr := jsonRecord{
+		Op:        r.Op,
+		Paths:     r.Paths,
+		Wd:        r.Wd,
+		Args:      jsonValues(r.Args),
+		Results:   jsonValues(r.Results),
+		Start:     r.Start,
+		Duration:  r.Duration,
+		Goroutine: r.Goroutine,
+	}
+	if r.Err != nil {
+		jr.Err = r.Err.Error()
+	}
+	return json.Marshal(jr)
+}
+
+func jsonValues(vals []interface{}) []interface{} {
+	if vals == nil {
+		return nil
+	}
+	l := make([]interface{}, len(vals))
+	for i, v := range vals {
+		l[i] = jsonValue(v)
+	}
+	return l
+}
+
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case error:
+		return v.Error()
+	case fs.FileMode:
+		return v.String()
+	case interface{ Name() string }:
+		switch v := v.(type) {
+		case fs.FileInfo:
+			return map[string]interface{}{
+				"name": v.Name(),
+				"mode": v.Mode().String(),
+				"size": v.Size(),
+			}
+		case fs.DirEntry:
+			return map[string]interface{}{
+				"name": v.Name(),
+				"mode": v.Type().String(),
+			}
+		}
+		return v.Name()
+	case []fs.DirEntry:
+		l := make([]interface{}, len(v))
+		for i, e := range v {
+			l[i] = jsonValue(e)
+		}
+		return l
+	case io.Reader, io.Writer:
+		return nil
+	}
+	return v
+}
+
+type recos struct {
+	osa.I
+	rec *recorder
+}
+
+// New creates a new recos instance that wraps o and records all calls.
+func New(o osa.I) recos {
+	rec := &recorder{o: o}
+	return recos{hookos.New(o, rec.hook), rec}
+}
+
+// Records returns all calls recorded so far, in the order they completed.
+func (r recos) Records() []Record {
+	r.rec.mu.Lock()
+	defer r.rec.mu.Unlock()
+	records := make([]Record, len(r.rec.records))
+	copy(records, r.rec.records)
+	return records
+}
+
+// Reset clears all recorded calls.
+func (r recos) Reset() {
+	r.rec.mu.Lock()
+	defer r.rec.mu.Unlock()
+	r.rec.records = nil
+}
+
+// WriteJSON writes all calls recorded so far to w as JSON Lines, i.e. one JSON
+// object per line.
+func (r recos) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, rec := range r.Records() {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type recorder struct {
+	o       osa.I
+	mu      sync.Mutex
+	records []Record
+}
+
+func (rec *recorder) hook(c hookos.Call, next hookos.Next) []interface{} {
+	wd := rec.wd(c)
+	start := time.Now()
+	res := next(c)
+	r := Record{
+		Op:        c.Op,
+		Paths:     c.Paths,
+		Wd:        wd,
+		Args:      copyArgs(c.Args),
+		Results:   res,
+		Start:     start,
+		Duration:  time.Since(start),
+		Goroutine: goroutineID(),
+	}
+	if n := len(res); n > 0 && hookos.ReturnsError(c.Op) {
+		r.Err, _ = res[n-1].(error)
+		r.Results = res[:n-1]
+	}
+	rec.mu.Lock()
+	rec.records = append(rec.records, r)
+	rec.mu.Unlock()
+	return res
+}
+
+// wd returns the working directory if a path of c is relative, querying the
+// wrapped implementation, so that the query is not recorded.
+func (rec *recorder) wd(c hookos.Call) string {
+	for _, p := range c.Paths {
+		if !filepath.IsAbs(p) {
+			wd, _ := rec.o.Getwd()
+			return wd
+		}
+	}
+	return ""
+}
+
+// copyArgs copies args, including byte slices, which callers may reuse.
+func copyArgs(args []interface{}) []interface{} {
+	if args == nil {
+		return nil
+	}
+	l := make([]interface{}, len(args))
+	for i, a := range args {
+		if b, ok := a.([]byte); ok {
+			a = append([]byte{}, b...)
+		}
+		l[i] = a
+	}
+	return l
+}
+
+// goroutineID returns the ID of the current goroutine.
+func goroutineID() uint64 {
+	var buf [64]byte
+	s := string(buf[:runtime.Stack(buf[:], false)])
+	s = strings.TrimPrefix(s, "goroutine ")
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		s = s[:i]
+	}
+	id, _ := strconv.ParseUint(s, 10, 64)
+	return id
+}
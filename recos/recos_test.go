@@ -0,0 +1,135 @@
+package recos_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/recos"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecords(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	file := testos.Join(dir, "file")
+	r := recos.New(v)
+
+	require.NoError(t, r.WriteFile(file, []byte("data"), 0644))
+	_, err := r.ReadFile(testos.Join(dir, "missing"))
+	require.Error(t, err)
+	f, err := r.Open(file)
+	require.NoError(t, err)
+
+	records := r.Records()
+	require.Len(t, records, 3)
+
+	assert.Equal(t, "WriteFile", records[0].Op)
+	assert.Equal(t, []string{file}, records[0].Paths)
+	assert.Equal(t, []interface{}{file, []byte("data"), fs.FileMode(0644)}, records[0].Args)
+	assert.Empty(t, records[0].Results)
+	assert.NoError(t, records[0].Err)
+
+	assert.Equal(t, "ReadFile", records[1].Op)
+	assert.Equal(t, []interface{}{[]byte(nil)}, records[1].Results)
+	assert.True(t, v.IsNotExist(records[1].Err))
+
+	assert.Equal(t, "Open", records[2].Op)
+	require.Len(t, records[2].Results, 1)
+	assert.NotNil(t, records[2].Results[0])
+
+	r.Reset()
+	buf := make([]byte, 2)
+	_, err = f.Read(buf)
+	require.NoError(t, err)
+	buf[0] = 'x'
+	records = r.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, "File.Read", records[0].Op)
+	assert.Equal(t, []string{file}, records[0].Paths)
+	assert.Equal(t, []interface{}{[]byte("da")}, records[0].Args)
+	assert.Equal(t, []interface{}{2}, records[0].Results)
+	assert.NotZero(t, records[0].Goroutine)
+	assert.False(t, records[0].Start.IsZero())
+}
+
+func TestGoroutines(t *testing.T) {
+	r := recos.New(vos.New())
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Getenv("KEY")
+		}()
+	}
+	wg.Wait()
+
+	ids := make(map[uint64]bool)
+	for _, rec := range r.Records() {
+		ids[rec.Goroutine] = true
+	}
+	assert.Len(t, ids, 4)
+}
+
+func TestWriteJSON(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	r := recos.New(v)
+
+	testos.RequireWrite(t, r, testos.Join(dir, "file"), "data")
+	_, err := r.Stat(testos.Join(dir, "missing"))
+	require.Error(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteJSON(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+
+	var got []map[string]interface{}
+	for _, line := range lines {
+		var rec map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+		got = append(got, rec)
+	}
+	assert.Equal(t, "WriteFile", got[0]["op"])
+	assert.Equal(t, []interface{}{testos.Join(dir, "file"), "data", "-rw-------"}, got[0]["args"])
+	assert.Equal(t, "ReadFile", got[1]["op"])
+	assert.Equal(t, []interface{}{"data"}, got[1]["results"])
+	assert.Equal(t, "Stat", got[2]["op"])
+	assert.Contains(t, got[2]["err"], "missing")
+	assert.NotContains(t, got[0], "err")
+}
+
+func TestWritten(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	r := recos.New(v)
+
+	file := testos.Join(dir, "file")
+	testos.RequireWrite(t, r, file, "data")
+	f, err := r.OpenFile(file, osa.O_RDONLY, 0)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, r.Symlink("/elsewhere", testos.Join(dir, "link")))
+	tmp, err := r.MkdirTemp(dir, "tmp")
+	require.NoError(t, err)
+
+	var written []string
+	for _, rec := range r.Records() {
+		written = append(written, rec.Written()...)
+	}
+	assert.Equal(t, []string{file, testos.Join(dir, "link"), tmp}, written)
+
+	testos.AssertNoWritesOutside(t, r.Records(), dir)
+	testos.AssertCalledOnce(t, r.Records(), "ReadFile", file)
+	testos.AssertCalls(t, r.Records(), "OpenFile", file, 1)
+	testos.AssertCalls(t, r.Records(), "Remove", file, 0)
+}
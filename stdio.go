@@ -25,6 +25,6 @@ type stdin struct{}
 type stdout struct{}
 type stderr struct{}
 
-func (stdin) Read(p []byte) (int, error)   { return osa.Stdin().Read(p) }
-func (stdout) Write(p []byte) (int, error) { return osa.Stdout().Write(p) }
-func (stderr) Write(p []byte) (int, error) { return osa.Stderr().Write(p) }
+func (stdin) Read(p []byte) (int, error)   { return Current().Stdin().Read(p) }
+func (stdout) Write(p []byte) (int, error) { return Current().Stdout().Write(p) }
+func (stderr) Write(p []byte) (int, error) { return Current().Stderr().Write(p) }
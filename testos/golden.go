@@ -0,0 +1,153 @@
+package testos
+
+import (
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	osaPkg "github.com/echocrow/osa"
+	"github.com/echocrow/osa/oos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("testos.update", false, "update golden directories of testos")
+
+// updating reports whether golden directories are to be updated, via either
+// the -testos.update flag, or an -update flag defined by the test package.
+func updating() bool {
+	if *update {
+		return true
+	}
+	f := flag.Lookup("update")
+	if f == nil {
+		return false
+	}
+	g, ok := f.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+	b, _ := g.Get().(bool)
+	return b
+}
+
+// AssertGolden asserts that a directory matches a golden directory, e.g. in
+// testdata, reporting all missing, extra, and differing entries at once.
+//
+// When tests run with the -testos.update flag, the golden directory is
+// replaced with a copy of the directory instead:
+//
+//	go test . -testos.update
+//
+// If the test package defines its own boolean -update flag, that flag updates
+// golden directories as well.
+//
+// File data, symlink targets, and entry types are compared. Modes and
+// modification times are not, as version control does not keep them; the
+// golden copy keeps the executable bits of files only.
+//
+// The golden directory is always read and written via the real file system,
+// regardless of patches of the OS abstraction.
+func AssertGolden(t *testing.T, osa osaPkg.I, dir string, golden string) bool {
+	msg, err := compareGolden(osa, dir, golden)
+	if !assert.NoError(t, err) {
+		return false
+	}
+	if msg != "" {
+		return assert.Fail(t, msg)
+	}
+	return true
+}
+
+// RequireGolden requires that a directory matches a golden directory. See
+// AssertGolden.
+func RequireGolden(t *testing.T, osa osaPkg.I, dir string, golden string) {
+	msg, err := compareGolden(osa, dir, golden)
+	require.NoError(t, err)
+	if msg != "" {
+		require.Fail(t, msg)
+	}
+}
+
+// compareGolden describes the differences between a directory and a golden
+// directory, or returns an empty string if there are none. It updates the
+// golden directory first if requested.
+func compareGolden(osa osaPkg.I, dir string, golden string) (string, error) {
+	if updating() {
+		if err := updateGolden(osa, dir, golden); err != nil {
+			return "", err
+		}
+	}
+	changes, err := vos.DiffDirs(oos.New(), golden, osa, dir)
+	if err != nil {
+		return "", err
+	}
+	msg := describeChanges(dir, changes.Ignore(vos.ModeChanged|vos.MtimeChanged))
+	if msg != "" {
+		msg += "\nrun tests with -testos.update to update " + golden + "\n"
+	}
+	return msg, nil
+}
+
+// updateGolden replaces a golden directory with a copy of a directory.
+func updateGolden(osa osaPkg.I, dir string, golden string) error {
+	if err := os.RemoveAll(golden); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(golden, 0755); err != nil {
+		return err
+	}
+	var walk func(dir, dst string) error
+	walk = func(dir, dst string) error {
+		entries, err := osa.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			name := filepath.Join(dir, e.Name())
+			target := filepath.Join(dst, e.Name())
+			switch {
+			case e.Type()&fs.ModeSymlink != 0:
+				var link string
+				if link, err = osa.Readlink(name); err == nil {
+					err = os.Symlink(link, target)
+				}
+			case e.IsDir():
+				if err = os.Mkdir(target, 0755); err == nil {
+					err = walk(name, target)
+				}
+			case e.Type().IsRegular():
+				err = copyGoldenFile(osa, name, target)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(dir, golden)
+}
+
+// copyGoldenFile copies a file into a golden directory, keeping its
+// executable bits.
+func copyGoldenFile(osa osaPkg.I, name, target string) error {
+	fi, err := osa.Stat(name)
+	if err != nil {
+		return err
+	}
+	data, err := osa.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	perm := fs.FileMode(0644)
+	if fi.Mode()&0111 != 0 {
+		perm = 0755
+	}
+	if err := os.WriteFile(target, data, perm); err != nil {
+		return err
+	}
+	return os.Chmod(target, perm)
+}
@@ -0,0 +1,79 @@
+package testos_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// update is defined by the test package, as is common for golden tests.
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGolden(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	testos.Tree{"a.txt": "a\n", "sub/b.txt": "b\n"}.Write(t, v, dir)
+	testos.RequireGolden(t, v, dir, "testdata/golden")
+
+	testos.RequireWrite(t, v, testos.Join(dir, "a.txt"), "changed\n")
+	mock := new(testing.T)
+	assert.False(t, testos.AssertGolden(mock, v, dir, "testdata/golden"))
+	assert.True(t, mock.Failed())
+}
+
+func TestGoldenUpdate(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "golden")
+	require.NoError(t, os.MkdirAll(filepath.Join(golden, "stale"), 0755))
+
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	testos.Tree{
+		"a.txt":   "a\n",
+		"sub/b":   testos.File{Data: "#!/bin/sh\n", Mode: 0700},
+		"link":    testos.Symlink("a.txt"),
+		"empty/":  testos.Dir{},
+		"sub/c/d": "d",
+	}.Write(t, v, dir)
+
+	require.NoError(t, flag.Set("testos.update", "true"))
+	defer func() { require.NoError(t, flag.Set("testos.update", "false")) }()
+	testos.RequireGolden(t, v, dir, golden)
+	require.NoError(t, flag.Set("testos.update", "false"))
+	testos.RequireGolden(t, v, dir, golden)
+
+	// The -update flag of the test package updates golden directories too.
+	testos.RequireWrite(t, v, testos.Join(dir, "a.txt"), "changed\n")
+	*update = true
+	testos.RequireGolden(t, v, dir, golden)
+	*update = false
+	testos.RequireGolden(t, v, dir, golden)
+	testos.RequireWrite(t, v, testos.Join(dir, "a.txt"), "a\n")
+	require.NoError(t, flag.Set("testos.update", "true"))
+	testos.RequireGolden(t, v, dir, golden)
+	require.NoError(t, flag.Set("testos.update", "false"))
+
+	var got []string
+	err := filepath.Walk(golden, func(p string, fi os.FileInfo, err error) error {
+		rel, _ := filepath.Rel(golden, p)
+		got = append(got, filepath.ToSlash(rel)+" "+fi.Mode().String())
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		". drwxr-xr-x",
+		"a.txt -rw-r--r--",
+		"empty drwxr-xr-x",
+		"link Lrwxrwxrwx",
+		"sub drwxr-xr-x",
+		"sub/b -rwxr-xr-x",
+		"sub/c drwxr-xr-x",
+		"sub/c/d -rw-r--r--",
+	}, "\n"), strings.Join(got, "\n"))
+}
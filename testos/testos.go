@@ -14,6 +14,12 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// Patch monkey-patches the OS abstraction for the duration of a test, and
+// resets it on cleanup. See osa.Patch.
+func Patch(t testing.TB, osa osaPkg.I) {
+	t.Cleanup(osaPkg.Patch(osa))
+}
+
 // RequireEmptyWrite requires that writing an empty file succeeds.
 func RequireEmptyWrite(t *testing.T, osa osaPkg.I, path string) {
 	RequireWrite(t, osa, path, "")
@@ -0,0 +1,20 @@
+package testos_test
+
+import (
+	"testing"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatch(t *testing.T) {
+	org := osa.Current()
+	v := vos.New()
+	t.Run("patch", func(t *testing.T) {
+		testos.Patch(t, v)
+		assert.Exactly(t, v, osa.Current())
+	})
+	assert.Exactly(t, org, osa.Current(), "resets on cleanup")
+}
@@ -0,0 +1,89 @@
+package testos
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/echocrow/osa/recos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// AssertNoWritesOutside asserts that no recorded call modified a path outside
+// of a directory. Relative paths are resolved against the working directory
+// of their call, and ".." components are resolved lexically.
+func AssertNoWritesOutside(t *testing.T, records []recos.Record, dir string) bool {
+	outside := writesOutside(records, dir)
+	return assert.Emptyf(t, outside, "expected no writes outside %s", dir)
+}
+
+// RequireNoWritesOutside requires that no recorded call modified a path
+// outside of a directory.
+func RequireNoWritesOutside(t *testing.T, records []recos.Record, dir string) {
+	outside := writesOutside(records, dir)
+	require.Emptyf(t, outside, "expected no writes outside %s", dir)
+}
+
+// AssertCalls asserts that an op was called on a path a certain number of
+// times.
+func AssertCalls(t *testing.T, records []recos.Record, op, path string, want int) bool {
+	got := countCalls(records, op, path)
+	return assert.Equalf(t, want, got, "expected %s calls on %s", op, path)
+}
+
+// RequireCalls requires that an op was called on a path a certain number of
+// times.
+func RequireCalls(t *testing.T, records []recos.Record, op, path string, want int) {
+	got := countCalls(records, op, path)
+	require.Equalf(t, want, got, "expected %s calls on %s", op, path)
+}
+
+// AssertCalledOnce asserts that an op was called on a path exactly once.
+func AssertCalledOnce(t *testing.T, records []recos.Record, op, path string) bool {
+	return AssertCalls(t, records, op, path, 1)
+}
+
+// writesOutside returns the paths recorded calls modified outside of dir.
+// Relative paths are resolved against the working directory of their call.
+func writesOutside(records []recos.Record, dir string) []string {
+	var outside []string
+	for _, r := range records {
+		for _, p := range r.Written() {
+			if !filepath.IsAbs(p) && r.Wd != "" {
+				p = filepath.Join(r.Wd, p)
+			}
+			if !within(p, dir) {
+				outside = append(outside, r.Op+" "+p)
+			}
+		}
+	}
+	return outside
+}
+
+// countCalls counts the recorded calls of op on path.
+func countCalls(records []recos.Record, op, path string) int {
+	n := 0
+	for _, r := range records {
+		if r.Op != op {
+			continue
+		}
+		for _, p := range r.Paths {
+			if filepath.Clean(p) == filepath.Clean(path) {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+// within reports whether path lies within dir, comparing clean paths
+// lexically.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
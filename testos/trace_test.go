@@ -0,0 +1,28 @@
+package testos_test
+
+import (
+	"testing"
+
+	"github.com/echocrow/osa/recos"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoWritesOutside(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	r := recos.New(v)
+
+	require.NoError(t, r.Chdir(dir))
+	testos.RequireWrite(t, r, "file", "data")
+	testos.RequireMkdir(t, r, "other")
+	testos.AssertNoWritesOutside(t, r.Records(), dir)
+	testos.AssertCalledOnce(t, r.Records(), "WriteFile", "file")
+
+	testos.RequireWrite(t, r, testos.Join("..", "escaped"), "data")
+	mock := new(testing.T)
+	assert.False(t, testos.AssertNoWritesOutside(mock, r.Records(), dir))
+	assert.True(t, mock.Failed())
+}
@@ -0,0 +1,258 @@
+package testos
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	osaPkg "github.com/echocrow/osa"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Tree declares a file tree, e.g.:
+//
+//	testos.Tree{
+//		"a/b.txt": "x",
+//		"c/":      testos.Dir{},
+//		"d":       testos.Dir{"e.sh": testos.File{Data: "#!/bin/sh", Mode: 0700}},
+//		"f":       testos.Symlink("a/b.txt"),
+//	}
+//
+// Keys are slash-separated paths; missing parent directories are implied.
+// Values are file data (a string or []byte), a File, a Symlink, or a Dir
+// (or Tree) of nested entries.
+type Tree map[string]interface{}
+
+// Dir declares a directory and its entries within a Tree.
+type Dir map[string]interface{}
+
+// File declares a file with a mode within a Tree.
+type File struct {
+	Data string
+	// Mode holds the permission bits of the file. If zero, files are written
+	// with mode 0600, and their mode is not compared.
+	Mode fs.FileMode
+}
+
+// Symlink declares a symbolic link to a target within a Tree.
+type Symlink string
+
+// A TreeOption configures the comparison of a Tree.
+type TreeOption func(c *treeConfig)
+
+type treeConfig struct {
+	ignoreExtra bool
+}
+
+// IgnoreExtra ignores entries that are not part of the Tree.
+func IgnoreExtra() TreeOption {
+	return func(c *treeConfig) {
+		c.ignoreExtra = true
+	}
+}
+
+// Write requires that writing the tree into a directory succeeds. Missing
+// directories are created with mode 0700, and existing files are overwritten.
+func (tr Tree) Write(t *testing.T, osa osaPkg.I, root string) {
+	entries, err := tr.flatten()
+	require.NoError(t, err)
+	err = writeTree(osa, root, entries)
+	require.NoError(t, err)
+}
+
+// AssertEqual asserts that a directory holds exactly the entries of the tree,
+// reporting all missing, extra, and differing entries at once.
+//
+// File data, file modes declared via File, symlink targets, and entry types
+// are compared. Modification times and directory modes are not.
+func (tr Tree) AssertEqual(t *testing.T, osa osaPkg.I, root string, opts ...TreeOption) bool {
+	msg, err := tr.Diff(osa, root, opts...)
+	if !assert.NoError(t, err) {
+		return false
+	}
+	if msg != "" {
+		return assert.Fail(t, msg)
+	}
+	return true
+}
+
+// RequireEqual requires that a directory holds exactly the entries of the
+// tree. See AssertEqual.
+func (tr Tree) RequireEqual(t *testing.T, osa osaPkg.I, root string, opts ...TreeOption) {
+	msg, err := tr.Diff(osa, root, opts...)
+	require.NoError(t, err)
+	if msg != "" {
+		require.Fail(t, msg)
+	}
+}
+
+// Diff describes all differences between the tree and a directory, or returns
+// an empty string if there are none. See AssertEqual.
+func (tr Tree) Diff(osa osaPkg.I, root string, opts ...TreeOption) (string, error) {
+	var c treeConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	entries, err := tr.flatten()
+	if err != nil {
+		return "", err
+	}
+	want := vos.New()
+	dir := vos.MkTempDir(want)
+	if err := writeTree(want, dir, entries); err != nil {
+		return "", err
+	}
+	changes, err := vos.DiffDirs(want, dir, osa, root)
+	if err != nil {
+		return "", err
+	}
+
+	var diffs vos.Changes
+	for _, ch := range changes.Ignore(vos.MtimeChanged) {
+		if f, ok := entries[ch.Path].(File); !ok || f.Mode == 0 {
+			ch.Kind &^= vos.ModeChanged
+		}
+		if ch.Kind == vos.Added && c.ignoreExtra || ch.Kind == 0 {
+			continue
+		}
+		diffs = append(diffs, ch)
+	}
+	return describeChanges(root, diffs), nil
+}
+
+// describeChanges describes the differences of a directory from its expected
+// entries, or returns an empty string if there are none.
+func describeChanges(root string, diffs vos.Changes) string {
+	if len(diffs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "unexpected tree in %s:\n", root)
+	for _, ch := range diffs {
+		switch ch.Kind {
+		case vos.Added:
+			fmt.Fprintf(&b, "\textra %s\n", ch.Path)
+		case vos.Removed:
+			fmt.Fprintf(&b, "\tmissing %s\n", ch.Path)
+		default:
+			fmt.Fprintf(&b, "\tdiffering %s (%s)\n", ch.Path, ch.Kind)
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(diffs.String())
+	return b.String()
+}
+
+// flatten returns all entries of the tree, including implied directories,
+// keyed by their slash-separated paths. Directories map to Dir{}, and file
+// data maps to File.
+func (tr Tree) flatten() (map[string]interface{}, error) {
+	entries := make(map[string]interface{})
+	err := flattenDir(entries, "", tr)
+	return entries, err
+}
+
+func flattenDir(entries map[string]interface{}, dir string, tr map[string]interface{}) error {
+	for key, val := range tr {
+		name := strings.TrimSuffix(key, "/")
+		if !fs.ValidPath(name) || name == "." {
+			return fmt.Errorf("invalid tree path %q", key)
+		}
+		p := path.Join(dir, name)
+		for parent := path.Dir(p); parent != "." && parent != dir; parent = path.Dir(parent) {
+			if err := addTreeEntry(entries, parent, Dir{}); err != nil {
+				return err
+			}
+		}
+		var e interface{}
+		switch val := val.(type) {
+		case string:
+			e = File{Data: val}
+		case []byte:
+			e = File{Data: string(val)}
+		case File, Symlink:
+			e = val
+		case Dir:
+			e = Dir{}
+			if err := flattenDir(entries, p, val); err != nil {
+				return err
+			}
+		case Tree:
+			e = Dir{}
+			if err := flattenDir(entries, p, val); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported tree entry %s of type %T", p, val)
+		}
+		if _, ok := e.(Dir); !ok && strings.HasSuffix(key, "/") {
+			return fmt.Errorf("invalid tree path %q of non-directory", key)
+		}
+		if err := addTreeEntry(entries, p, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addTreeEntry adds an entry, reporting conflicting declarations.
+func addTreeEntry(entries map[string]interface{}, p string, e interface{}) error {
+	if prev, ok := entries[p]; ok {
+		_, prevDir := prev.(Dir)
+		_, isDir := e.(Dir)
+		if !prevDir || !isDir {
+			return fmt.Errorf("conflicting tree entries for %s", p)
+		}
+		return nil
+	}
+	entries[p] = e
+	return nil
+}
+
+// writeTree writes flattened tree entries into a directory.
+func writeTree(osa osaPkg.I, root string, entries map[string]interface{}) error {
+	paths := make([]string, 0, len(entries))
+	for p := range entries {
+		paths = append(paths, p)
+	}
+	// Sorting creates directories before their entries.
+	sort.Strings(paths)
+	if err := osa.MkdirAll(root, 0700); err != nil {
+		return err
+	}
+	for _, p := range paths {
+		name := filepath.Join(root, filepath.FromSlash(p))
+		var err error
+		switch e := entries[p].(type) {
+		case Dir:
+			err = osa.MkdirAll(name, 0700)
+		case File:
+			err = writeTreeFile(osa, name, e)
+		case Symlink:
+			if err = osa.Remove(name); err == nil || osa.IsNotExist(err) {
+				err = osa.Symlink(string(e), name)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTreeFile(osa osaPkg.I, name string, f File) error {
+	mode := f.Mode
+	if mode == 0 {
+		mode = 0600
+	}
+	if err := osa.WriteFile(name, []byte(f.Data), mode); err != nil {
+		return err
+	}
+	return osa.Chmod(name, mode)
+}
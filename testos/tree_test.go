@@ -0,0 +1,103 @@
+package testos_test
+
+import (
+	"testing"
+
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTree(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	tree := testos.Tree{
+		"a/b.txt": "x",
+		"c/":      testos.Dir{},
+		"d": testos.Dir{
+			"e.sh":  testos.File{Data: "#!/bin/sh\n", Mode: 0700},
+			"f/g":   []byte("g"),
+			"h/i/j": testos.Tree{"k": "k"},
+		},
+		"l": testos.Symlink("a/b.txt"),
+	}
+	tree.Write(t, v, dir)
+	testos.AssertFileData(t, v, testos.Join(dir, "a", "b.txt"), "x")
+	testos.AssertIsEmpty(t, v, testos.Join(dir, "c"))
+	testos.AssertFileData(t, v, testos.Join(dir, "d", "f", "g"), "g")
+	testos.AssertFileData(t, v, testos.Join(dir, "d", "h", "i", "j", "k"), "k")
+	testos.AssertFileData(t, v, testos.Join(dir, "l"), "x")
+	fi, err := v.Stat(testos.Join(dir, "d", "e.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, "-rwx------", fi.Mode().String())
+
+	tree.RequireEqual(t, v, dir)
+	testos.Tree{"a/b.txt": "x"}.RequireEqual(t, v, dir, testos.IgnoreExtra())
+
+	mock := new(testing.T)
+	assert.False(t, testos.Tree{"a/b.txt": "x"}.AssertEqual(mock, v, dir))
+	assert.True(t, mock.Failed())
+}
+
+func TestTreeDiff(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	testos.Tree{
+		"same":  "same",
+		"data":  "a\nb\n",
+		"mode":  testos.File{Data: "mode", Mode: 0600},
+		"type":  "file",
+		"extra": "extra",
+	}.Write(t, v, dir)
+
+	want := testos.Tree{
+		"same":    "same",
+		"data":    "a\nc\n",
+		"mode":    testos.File{Data: "mode", Mode: 0644},
+		"type/":   testos.Dir{},
+		"missing": "missing",
+	}
+	msg, err := want.Diff(v, dir)
+	require.NoError(t, err)
+	assert.Equal(t, `unexpected tree in `+dir+`:
+	differing data (content)
+	extra extra
+	missing missing
+	differing mode (mode)
+	differing type (type)
+
+diff a/data b/data
+--- a/data
++++ b/data
+@@ -1,2 +1,2 @@
+ a
+-c
++b
+diff a/extra b/extra
+new file mode -rw-------
+--- /dev/null
++++ b/extra
+@@ -0,0 +1 @@
++extra
+\ No newline at end of file
+diff a/missing b/missing
+deleted file mode -rw-------
+--- a/missing
++++ /dev/null
+@@ -1 +0,0 @@
+-missing
+\ No newline at end of file
+diff a/mode b/mode
+old mode -rw-r--r--
+new mode -rw-------
+diff a/type b/type
+old directory mode drwx------
+new file mode -rw-------
+--- a/type
++++ b/type
+@@ -0,0 +1 @@
++file
+\ No newline at end of file
+`, msg)
+}
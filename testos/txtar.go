@@ -0,0 +1,214 @@
+package testos
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	osaPkg "github.com/echocrow/osa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RequireTxtar requires that writing the files of a txtar archive into a
+// directory succeeds, e.g.:
+//
+//	testos.RequireTxtar(t, v, dir, `
+//	-- go.mod --
+//	module example.com/m
+//	-- cmd/main.go --
+//	package main
+//	-- empty/ --
+//	`)
+//
+// Txtar is the plain-text archive format of the Go toolchain's script tests
+// (golang.org/x/tools/txtar). The comment before the first file is ignored.
+// File names are slash-separated paths relative to dir; names ending in a
+// slash denote (empty) directories. Missing parent directories are created as
+// needed, and existing files are overwritten.
+func RequireTxtar(t *testing.T, osa osaPkg.I, dir string, archive string) {
+	files, err := parseTxtar([]byte(archive))
+	require.NoError(t, err)
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f.name))
+		if strings.HasSuffix(f.name, "/") {
+			RequireMkdirAll(t, osa, p)
+			continue
+		}
+		RequireMkdirAll(t, osa, filepath.Dir(p))
+		RequireWrite(t, osa, p, string(f.data))
+	}
+}
+
+// RequireTxtarFile requires that writing the files of a txtar archive file
+// into a directory succeeds. See RequireTxtar.
+//
+// The archive file is always read from the real file system, regardless of
+// patches of the OS abstraction, e.g. from testdata.
+func RequireTxtarFile(t *testing.T, osa osaPkg.I, dir string, name string) {
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	RequireTxtar(t, osa, dir, string(data))
+}
+
+// RequireReadTxtar requires that reading a directory as a txtar archive
+// succeeds, and returns the archive.
+//
+// The archive lists all files in lexical order of their paths, followed by a
+// newline if their data lacks one, and empty directories with a trailing
+// slash. Other entries, e.g. symbolic links, are omitted. See RequireTxtar.
+func RequireReadTxtar(t *testing.T, osa osaPkg.I, dir string) string {
+	archive, err := readTxtar(osa, dir)
+	require.NoError(t, err)
+	return archive
+}
+
+// AssertTxtarData asserts that a directory holds exactly the files and empty
+// directories of a txtar archive. See RequireReadTxtar.
+func AssertTxtarData(t *testing.T, osa osaPkg.I, dir string, want string) bool {
+	ok := true
+	got, err := readTxtar(osa, dir)
+	ok = ok && assert.NoError(t, err)
+	files, err := parseTxtar([]byte(want))
+	ok = ok && assert.NoError(t, err, "invalid txtar archive")
+	ok = ok && assert.Equalf(t, formatTxtar(files), got, "unexpected contents of %s", dir)
+	return ok
+}
+
+// RequireTxtarData requires that a directory holds exactly the files and
+// empty directories of a txtar archive. See RequireReadTxtar.
+func RequireTxtarData(t *testing.T, osa osaPkg.I, dir string, want string) {
+	got, err := readTxtar(osa, dir)
+	require.NoError(t, err)
+	files, err := parseTxtar([]byte(want))
+	require.NoError(t, err, "invalid txtar archive")
+	require.Equalf(t, formatTxtar(files), got, "unexpected contents of %s", dir)
+}
+
+// txtarFile is a file of a txtar archive.
+type txtarFile struct {
+	name string
+	data []byte
+}
+
+var (
+	txtarNewline       = []byte("\n")
+	txtarMarker        = []byte("-- ")
+	txtarMarkerEnd     = []byte(" --")
+	txtarNewlineMarker = []byte("\n-- ")
+)
+
+// parseTxtar parses the files of a txtar archive, dropping its comment.
+func parseTxtar(data []byte) ([]txtarFile, error) {
+	var files []txtarFile
+	_, name, data := findTxtarMarker(data)
+	for name != "" {
+		if err := checkTxtarName(name); err != nil {
+			return nil, err
+		}
+		f := txtarFile{name: name}
+		f.data, name, data = findTxtarMarker(data)
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// findTxtarMarker finds the next file marker in data, and returns the data
+// before the marker, the name of the marked file, and the data after the
+// marker. If there is no marker, it returns all data and an empty name.
+func findTxtarMarker(data []byte) (before []byte, name string, after []byte) {
+	var i int
+	for {
+		if name, after = isTxtarMarker(data[i:]); name != "" {
+			return data[:i], name, after
+		}
+		j := bytes.Index(data[i:], txtarNewlineMarker)
+		if j < 0 {
+			return data, "", nil
+		}
+		i += j + 1
+	}
+}
+
+// isTxtarMarker reports the name of the file marked by the first line of
+// data, and the data after the line, if the line is a file marker.
+func isTxtarMarker(data []byte) (name string, after []byte) {
+	if !bytes.HasPrefix(data, txtarMarker) {
+		return "", nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data, after = data[:i], data[i+1:]
+	}
+	if !bytes.HasSuffix(data, txtarMarkerEnd) || len(data) < len(txtarMarker)+len(txtarMarkerEnd) {
+		return "", nil
+	}
+	name = string(data[len(txtarMarker) : len(data)-len(txtarMarkerEnd)])
+	return strings.TrimSpace(name), after
+}
+
+// checkTxtarName reports an error if a file name does not denote a relative
+// path within a directory.
+func checkTxtarName(name string) error {
+	if !fs.ValidPath(strings.TrimSuffix(name, "/")) || name == "./" {
+		return fmt.Errorf("invalid txtar file name %q", name)
+	}
+	return nil
+}
+
+// formatTxtar formats the files of a txtar archive, sorted by name.
+func formatTxtar(files []txtarFile) string {
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].name < files[j].name
+	})
+	var b bytes.Buffer
+	for _, f := range files {
+		fmt.Fprintf(&b, "-- %s --\n", f.name)
+		b.Write(f.data)
+		if len(f.data) > 0 && !bytes.HasSuffix(f.data, txtarNewline) {
+			b.Write(txtarNewline)
+		}
+	}
+	return b.String()
+}
+
+// readTxtar reads the files and empty directories within a directory as a
+// txtar archive.
+func readTxtar(osa osaPkg.I, dir string) (string, error) {
+	var files []txtarFile
+	var walk func(dir, rel string) error
+	walk = func(dir, rel string) error {
+		entries, err := osa.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 && rel != "" {
+			files = append(files, txtarFile{name: rel + "/"})
+		}
+		for _, e := range entries {
+			name := filepath.Join(dir, e.Name())
+			p := path.Join(rel, e.Name())
+			switch {
+			case e.IsDir():
+				err = walk(name, p)
+			case e.Type().IsRegular():
+				var data []byte
+				data, err = osa.ReadFile(name)
+				files = append(files, txtarFile{name: p, data: data})
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(dir, ""); err != nil {
+		return "", err
+	}
+	return formatTxtar(files), nil
+}
@@ -0,0 +1,70 @@
+package testos_test
+
+import (
+	"testing"
+
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxtar(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	testos.RequireTxtar(t, v, dir, `
+This comment is ignored.
+-- b/c.txt --
+c
+-- a.txt --
+a
+-- -- dashes -- --
+-- not a marker
+--  spaced --
+-- empty/ --
+-- no-newline --
+x`)
+	testos.AssertFileData(t, v, testos.Join(dir, "a.txt"), "a\n")
+	testos.AssertFileData(t, v, testos.Join(dir, "b", "c.txt"), "c\n")
+	testos.AssertFileData(t, v, testos.Join(dir, "-- dashes --"), "-- not a marker\n")
+	testos.AssertFileData(t, v, testos.Join(dir, "spaced"), "")
+	testos.AssertFileData(t, v, testos.Join(dir, "no-newline"), "x")
+	testos.AssertIsEmpty(t, v, testos.Join(dir, "empty"))
+
+	require.NoError(t, v.Symlink("a.txt", testos.Join(dir, "link")))
+	assert.Equal(t, `-- -- dashes -- --
+-- not a marker
+-- a.txt --
+a
+-- b/c.txt --
+c
+-- empty/ --
+-- no-newline --
+x
+-- spaced --
+`, testos.RequireReadTxtar(t, v, dir))
+
+	testos.RequireTxtarData(t, v, dir, `
+-- a.txt --
+a
+-- no-newline --
+x
+-- b/c.txt --
+c
+-- spaced --
+-- -- dashes -- --
+-- not a marker
+-- empty/ --
+`)
+
+	mock := new(testing.T)
+	assert.False(t, testos.AssertTxtarData(mock, v, dir, "-- a.txt --\na\n"))
+	assert.True(t, mock.Failed())
+}
+
+func TestTxtarFile(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	testos.RequireTxtarFile(t, v, dir, "testdata/fixture.txtar")
+	testos.RequireTxtarData(t, v, dir, "-- a.txt --\nfrom file\n")
+}
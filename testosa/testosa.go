@@ -9,7 +9,9 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	osaPkg "github.com/echocrow/osa"
 	tos "github.com/echocrow/osa/testos"
@@ -224,6 +226,300 @@ func AssertOsa(
 		assert.True(t, osa.IsNotExist(err), "want not-exist error")
 	})
 
+	t.Run("OpenFileCreate", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "newFile")
+		tos.RequireNotExists(t, osa, path)
+
+		f, err := osa.OpenFile(path, osaPkg.O_RDWR|osaPkg.O_CREATE, 0600)
+		require.NoError(t, err)
+		require.NotNil(t, f)
+		assert.Equal(t, path, f.Name())
+
+		data := "some data"
+		n, err := f.Write([]byte(data))
+		assert.NoError(t, err)
+		assert.Equal(t, len(data), n)
+
+		stat, err := f.Stat()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(data)), stat.Size())
+
+		pos, err := f.Seek(0, io.SeekStart)
+		assert.NoError(t, err)
+		assert.Zero(t, pos)
+		got, err := io.ReadAll(f)
+		assert.NoError(t, err)
+		assert.Equal(t, data, string(got))
+
+		assert.NoError(t, f.Sync())
+		assert.NoError(t, f.Close())
+		assert.Error(t, f.Close())
+		assert.Error(t, f.Sync())
+
+		tos.AssertFileData(t, osa, path, data)
+	})
+	t.Run("OpenFileErrNotExist", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "missing")
+
+		f, err := osa.OpenFile(path, osaPkg.O_RDWR, 0600)
+		assert.Nil(t, f)
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+
+		f, err = osa.OpenFile(tos.Join(path, "file"), osaPkg.O_RDWR|osaPkg.O_CREATE, 0600)
+		assert.Nil(t, f)
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+	})
+	t.Run("OpenFileCreateErrTrailingSlash", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "newFile")
+		sep := string(osa.PathSeparator())
+
+		f, err := osa.OpenFile(path+sep, osaPkg.O_WRONLY|osaPkg.O_CREATE, 0600)
+		assert.Nil(t, f)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "is a directory")
+		}
+		err = osa.WriteFile(path+sep, []byte("some data"), 0600)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "is a directory")
+		}
+		tos.AssertNotExists(t, osa, path)
+	})
+	t.Run("OpenFileErrExcl", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "existing")
+		tos.RequireWrite(t, osa, path, "some data")
+
+		flag := osaPkg.O_WRONLY | osaPkg.O_CREATE | osaPkg.O_EXCL
+		f, err := osa.OpenFile(path, flag, 0600)
+		assert.Nil(t, f)
+		assert.True(t, osa.IsExist(err), "want exist error")
+		tos.AssertFileData(t, osa, path, "some data")
+
+		newPath := tos.Join(tmpDir, "new")
+		f, err = osa.OpenFile(newPath, flag, 0600)
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+		tos.AssertFileData(t, osa, newPath, "")
+	})
+	t.Run("OpenFileTrunc", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "file")
+		tos.RequireWrite(t, osa, path, "some long data")
+
+		f, err := osa.OpenFile(path, osaPkg.O_WRONLY|osaPkg.O_TRUNC, 0600)
+		require.NoError(t, err)
+		_, err = f.Write([]byte("new"))
+		assert.NoError(t, err)
+		assert.NoError(t, f.Close())
+
+		tos.AssertFileData(t, osa, path, "new")
+	})
+	t.Run("OpenFileNoTrunc", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "file")
+		tos.RequireWrite(t, osa, path, "some long data")
+
+		f, err := osa.OpenFile(path, osaPkg.O_WRONLY, 0600)
+		require.NoError(t, err)
+		_, err = f.Write([]byte("new"))
+		assert.NoError(t, err)
+		assert.NoError(t, f.Close())
+
+		tos.AssertFileData(t, osa, path, "newe long data")
+	})
+	t.Run("OpenFileAppend", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "log")
+		tos.RequireWrite(t, osa, path, "foo")
+
+		flag := osaPkg.O_APPEND | osaPkg.O_CREATE | osaPkg.O_WRONLY
+		f, err := osa.OpenFile(path, flag, 0600)
+		require.NoError(t, err)
+		_, err = f.Seek(0, io.SeekStart)
+		assert.NoError(t, err)
+		_, err = f.Write([]byte("bar"))
+		assert.NoError(t, err)
+		_, err = f.Write([]byte("baz"))
+		assert.NoError(t, err)
+		_, err = f.WriteAt([]byte("x"), 0)
+		assert.Error(t, err)
+		assert.NoError(t, f.Close())
+
+		tos.AssertFileData(t, osa, path, "foobarbaz")
+	})
+	t.Run("OpenFileReadOnly", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "file")
+		tos.RequireWrite(t, osa, path, "some data")
+
+		f, err := osa.OpenFile(path, osaPkg.O_RDONLY, 0)
+		require.NoError(t, err)
+		defer f.Close()
+
+		n, err := f.Write([]byte("x"))
+		assert.Zero(t, n)
+		assert.Error(t, err)
+		_, err = f.WriteAt([]byte("x"), 0)
+		assert.Error(t, err)
+		assert.Error(t, f.Truncate(0))
+
+		got, err := io.ReadAll(f)
+		assert.NoError(t, err)
+		assert.Equal(t, "some data", string(got))
+		tos.AssertFileData(t, osa, path, "some data")
+	})
+	t.Run("OpenFileWriteOnly", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "file")
+		tos.RequireWrite(t, osa, path, "some data")
+
+		f, err := osa.OpenFile(path, osaPkg.O_WRONLY, 0)
+		require.NoError(t, err)
+		defer f.Close()
+
+		n, err := f.Read(make([]byte, 4))
+		assert.Zero(t, n)
+		assert.Error(t, err)
+		_, err = f.ReadAt(make([]byte, 4), 0)
+		assert.Error(t, err)
+	})
+	t.Run("OpenFileReadWriteAt", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "file")
+		tos.RequireWrite(t, osa, path, "abcdef")
+
+		f, err := osa.OpenFile(path, osaPkg.O_RDWR, 0)
+		require.NoError(t, err)
+		defer f.Close()
+
+		n, err := f.WriteAt([]byte("XY"), 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		got := make([]byte, 4)
+		n, err = f.ReadAt(got, 3)
+		assert.ErrorIs(t, err, io.EOF)
+		assert.Equal(t, 3, n)
+		assert.Equal(t, "Yef", string(got[:n]))
+
+		n, err = f.WriteAt([]byte("gh"), 8)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		pos, err := f.Seek(0, io.SeekCurrent)
+		assert.NoError(t, err)
+		assert.Zero(t, pos, "want *At calls to leave offset untouched")
+
+		tos.AssertFileData(t, osa, path, "abXYef\x00\x00gh")
+	})
+	t.Run("OpenFileSeek", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "file")
+		tos.RequireWrite(t, osa, path, "0123456789")
+
+		f, err := osa.OpenFile(path, osaPkg.O_RDWR, 0)
+		require.NoError(t, err)
+		defer f.Close()
+
+		got := make([]byte, 2)
+		pos, err := f.Seek(-3, io.SeekEnd)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), pos)
+		_, err = f.Read(got)
+		assert.NoError(t, err)
+		assert.Equal(t, "78", string(got))
+
+		pos, err = f.Seek(-5, io.SeekCurrent)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), pos)
+		_, err = f.Write([]byte("ab"))
+		assert.NoError(t, err)
+
+		_, err = f.Seek(-1, io.SeekStart)
+		assert.Error(t, err)
+
+		tos.AssertFileData(t, osa, path, "0123ab6789")
+	})
+	t.Run("OpenFileTruncate", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "file")
+		tos.RequireWrite(t, osa, path, "some data")
+
+		f, err := osa.OpenFile(path, osaPkg.O_RDWR, 0)
+		require.NoError(t, err)
+		defer f.Close()
+
+		assert.NoError(t, f.Truncate(3))
+		tos.AssertFileData(t, osa, path, "som")
+		assert.NoError(t, f.Truncate(5))
+		tos.AssertFileData(t, osa, path, "som\x00\x00")
+	})
+	t.Run("OpenFileDir", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		tos.RequireEmptyWrite(t, osa, tos.Join(tmpDir, "file"))
+
+		f, err := osa.OpenFile(tmpDir, osaPkg.O_RDONLY, 0)
+		require.NoError(t, err)
+		entries, err := f.ReadDir(-1)
+		assert.NoError(t, err)
+		assert.Equal(t, []fsEntry{{"file", false}}, castFsEntries(entries, false))
+		_, err = f.Read(make([]byte, 1))
+		assert.Error(t, err)
+		assert.NoError(t, f.Close())
+
+		f, err = osa.OpenFile(tmpDir, osaPkg.O_WRONLY, 0)
+		assert.Nil(t, f)
+		assert.Error(t, err)
+	})
+	t.Run("OpenFileReadDirErrFile", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "file")
+		tos.RequireEmptyWrite(t, osa, path)
+
+		f, err := osa.OpenFile(path, osaPkg.O_RDONLY, 0)
+		require.NoError(t, err)
+		defer f.Close()
+
+		_, err = f.ReadDir(-1)
+		assert.Error(t, err)
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "file")
+
+		f, err := osa.Create(path)
+		require.NoError(t, err)
+		assert.Equal(t, path, f.Name())
+		_, err = f.Write([]byte("first data"))
+		assert.NoError(t, err)
+		assert.NoError(t, f.Close())
+		tos.AssertFileData(t, osa, path, "first data")
+
+		f, err = osa.Create(path)
+		require.NoError(t, err)
+		tos.AssertFileData(t, osa, path, "")
+		_, err = f.Write([]byte("second"))
+		assert.NoError(t, err)
+		_, err = f.Seek(0, io.SeekStart)
+		assert.NoError(t, err)
+		got, err := io.ReadAll(f)
+		assert.NoError(t, err)
+		assert.Equal(t, "second", string(got))
+		assert.NoError(t, f.Close())
+	})
+	t.Run("CreateErrNotExist", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "missing", "file")
+
+		f, err := osa.Create(path)
+		assert.Nil(t, f)
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+	})
+
 	t.Run("StatDir", func(t *testing.T) {
 		tmpDir := mkTempDir()
 
@@ -297,6 +593,23 @@ func AssertOsa(
 		assert.True(t, osa.IsNotExist(err))
 	})
 
+	t.Run("IsPermission", func(t *testing.T) {
+		err := &osaPkg.PathError{Op: "open", Path: "file", Err: fs.ErrPermission}
+		assert.True(t, osa.IsPermission(err))
+		assert.False(t, osa.IsPermission(fs.ErrNotExist))
+		assert.False(t, osa.IsPermission(nil))
+	})
+
+	t.Run("IsErrno", func(t *testing.T) {
+		pathErr := func(errno syscall.Errno) error {
+			return &osaPkg.PathError{Op: "open", Path: "file", Err: errno}
+		}
+		assert.True(t, osa.IsPermission(pathErr(syscall.EACCES)))
+		assert.True(t, osa.IsExist(pathErr(syscall.EEXIST)))
+		assert.True(t, osa.IsNotExist(pathErr(syscall.ENOENT)))
+		assert.False(t, osa.IsNotExist(pathErr(syscall.EIO)))
+	})
+
 	t.Run("IsPathSeparator", func(t *testing.T) {
 		sep := osa.PathSeparator()
 		notSep := uint8('a')
@@ -653,11 +966,449 @@ func AssertOsa(
 		assert.NoError(t, err)
 	})
 
+	t.Run("Modes", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		dir := tos.Join(tmpDir, "dir")
+		file := tos.Join(tmpDir, "file")
+		require.NoError(t, osa.Mkdir(dir, 0700))
+		require.NoError(t, osa.WriteFile(file, nil, 0600))
+
+		dirStat, err := osa.Stat(dir)
+		require.NoError(t, err)
+		assert.Equal(t, osaPkg.ModeDir|0700, dirStat.Mode())
+		fileStat, err := osa.Stat(file)
+		require.NoError(t, err)
+		assert.Equal(t, osaPkg.FileMode(0600), fileStat.Mode())
+
+		entries, err := osa.ReadDir(tmpDir)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, osaPkg.ModeDir, entries[0].Type())
+		assert.Equal(t, osaPkg.FileMode(0), entries[1].Type())
+		info, err := entries[1].Info()
+		require.NoError(t, err)
+		assert.Equal(t, osaPkg.FileMode(0600), info.Mode())
+	})
+	t.Run("Chmod", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		dir := tos.Join(tmpDir, "dir")
+		file := tos.Join(tmpDir, "file")
+		tos.RequireMkdir(t, osa, dir)
+		tos.RequireEmptyWrite(t, osa, file)
+
+		assert.NoError(t, osa.Chmod(file, 0640))
+		stat, err := osa.Stat(file)
+		require.NoError(t, err)
+		assert.Equal(t, osaPkg.FileMode(0640), stat.Mode())
+
+		assert.NoError(t, osa.Chmod(dir, 0750))
+		stat, err = osa.Stat(dir)
+		require.NoError(t, err)
+		assert.Equal(t, osaPkg.ModeDir|0750, stat.Mode())
+
+		f, err := osa.Open(file)
+		require.NoError(t, err)
+		stat, err = f.Stat()
+		assert.NoError(t, err)
+		assert.Equal(t, osaPkg.FileMode(0640), stat.Mode())
+		assert.NoError(t, f.Close())
+	})
+	t.Run("ChmodErrNotExist", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		err := osa.Chmod(tos.Join(tmpDir, "missing"), 0600)
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+	})
+
+	t.Run("Chtimes", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		file := tos.Join(tmpDir, "file")
+		tos.RequireEmptyWrite(t, osa, file)
+
+		atime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+		mtime := time.Date(2002, 3, 4, 5, 6, 7, 0, time.UTC)
+		assert.NoError(t, osa.Chtimes(file, atime, mtime))
+		stat, err := osa.Stat(file)
+		require.NoError(t, err)
+		assert.True(t, mtime.Equal(stat.ModTime()), "want mtime %v, got %v", mtime, stat.ModTime())
+
+		assert.NoError(t, osa.Chtimes(tmpDir, atime, mtime))
+		stat, err = osa.Stat(tmpDir)
+		require.NoError(t, err)
+		assert.True(t, mtime.Equal(stat.ModTime()), "want mtime %v, got %v", mtime, stat.ModTime())
+	})
+	t.Run("ChtimesErrNotExist", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		now := time.Now()
+		err := osa.Chtimes(tos.Join(tmpDir, "missing"), now, now)
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+	})
+	t.Run("ModTime", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		file := tos.Join(tmpDir, "file")
+		tos.RequireEmptyWrite(t, osa, file)
+
+		past := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+		require.NoError(t, osa.Chtimes(file, past, past))
+		require.NoError(t, osa.Chtimes(tmpDir, past, past))
+
+		tos.RequireWrite(t, osa, file, "new data")
+		stat, err := osa.Stat(file)
+		require.NoError(t, err)
+		assert.True(t, stat.ModTime().After(past), "want write to update mtime")
+
+		tos.RequireEmptyWrite(t, osa, tos.Join(tmpDir, "other"))
+		stat, err = osa.Stat(tmpDir)
+		require.NoError(t, err)
+		assert.True(t, stat.ModTime().After(past), "want new entry to update dir mtime")
+	})
+
+	t.Run("Link", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		file := tos.Join(tmpDir, "file")
+		tos.RequireWrite(t, osa, file, "some data")
+		tos.RequireMkdir(t, osa, tos.Join(tmpDir, "sub"))
+		link := tos.Join(tmpDir, "sub", "link")
+
+		assert.NoError(t, osa.Link(file, link))
+		tos.AssertFileData(t, osa, link, "some data")
+
+		tos.RequireWrite(t, osa, link, "new data")
+		tos.AssertFileData(t, osa, file, "new data")
+
+		f, err := osa.OpenFile(file, osaPkg.O_WRONLY|osaPkg.O_APPEND, 0)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(" and more"))
+		assert.NoError(t, err)
+		assert.NoError(t, f.Close())
+		tos.AssertFileData(t, osa, link, "new data and more")
+
+		assert.NoError(t, osa.Remove(file))
+		tos.AssertNotExists(t, osa, file)
+		tos.AssertFileData(t, osa, link, "new data and more")
+	})
+	t.Run("LinkErr", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		file := tos.Join(tmpDir, "file")
+		tos.RequireEmptyWrite(t, osa, file)
+		other := tos.Join(tmpDir, "other")
+		tos.RequireEmptyWrite(t, osa, other)
+		dir := tos.Join(tmpDir, "dir")
+		tos.RequireMkdir(t, osa, dir)
+
+		err := osa.Link(file, other)
+		assert.True(t, osa.IsExist(err), "want exist error")
+		err = osa.Link(tos.Join(tmpDir, "missing"), tos.Join(tmpDir, "new"))
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+		assert.Error(t, osa.Link(dir, tos.Join(tmpDir, "dirLink")))
+	})
+	t.Run("SameFile", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		file := tos.Join(tmpDir, "file")
+		tos.RequireWrite(t, osa, file, "some data")
+		other := tos.Join(tmpDir, "other")
+		tos.RequireWrite(t, osa, other, "some data")
+		link := tos.Join(tmpDir, "link")
+		require.NoError(t, osa.Link(file, link))
+		symlink := tos.Join(tmpDir, "symlink")
+		require.NoError(t, osa.Symlink(file, symlink))
+
+		stat := func(name string) osaPkg.FileInfo {
+			fi, err := osa.Stat(name)
+			require.NoError(t, err)
+			return fi
+		}
+		assert.True(t, osa.SameFile(stat(file), stat(file)))
+		assert.True(t, osa.SameFile(stat(file), stat(link)))
+		assert.True(t, osa.SameFile(stat(file), stat(symlink)))
+		assert.False(t, osa.SameFile(stat(file), stat(other)))
+		assert.False(t, osa.SameFile(stat(file), stat(tmpDir)))
+
+		lstat, err := osa.Lstat(symlink)
+		require.NoError(t, err)
+		assert.False(t, osa.SameFile(stat(file), lstat))
+
+		moved := tos.Join(tmpDir, "moved")
+		require.NoError(t, osa.Rename(link, moved))
+		assert.True(t, osa.SameFile(stat(file), stat(moved)))
+	})
+
+	t.Run("Symlink", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		file := tos.Join(tmpDir, "file")
+		tos.RequireWrite(t, osa, file, "some data")
+		link := tos.Join(tmpDir, "link")
+
+		assert.NoError(t, osa.Symlink(file, link))
+		tos.AssertFileData(t, osa, link, "some data")
+
+		got, err := osa.Readlink(link)
+		assert.NoError(t, err)
+		assert.Equal(t, file, got)
+
+		stat, err := osa.Stat(link)
+		require.NoError(t, err)
+		assert.Equal(t, "link", stat.Name())
+		assert.True(t, stat.Mode().IsRegular())
+
+		lstat, err := osa.Lstat(link)
+		require.NoError(t, err)
+		assert.Equal(t, "link", lstat.Name())
+		assert.Equal(t, osaPkg.ModeSymlink, lstat.Mode().Type())
+
+		lstat, err = osa.Lstat(file)
+		require.NoError(t, err)
+		assert.True(t, lstat.Mode().IsRegular())
+
+		tos.RequireWrite(t, osa, link, "new data")
+		tos.AssertFileData(t, osa, file, "new data")
+	})
+	t.Run("SymlinkRelative", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		releases := tos.Join(tmpDir, "releases")
+		tos.RequireMkdirAll(t, osa, tos.Join(releases, "v42"))
+		tos.RequireWrite(t, osa, tos.Join(releases, "v42", "VERSION"), "42")
+		tos.RequireMkdir(t, osa, tos.Join(tmpDir, "links"))
+
+		current := tos.Join(tmpDir, "current")
+		assert.NoError(t, osa.Symlink(tos.Join("releases", "v42"), current))
+		tos.AssertFileData(t, osa, tos.Join(current, "VERSION"), "42")
+		tos.AssertExistsIsDir(t, osa, current, true)
+
+		nested := tos.Join(tmpDir, "links", "version")
+		assert.NoError(t, osa.Symlink(tos.Join("..", "current", "VERSION"), nested))
+		tos.AssertFileData(t, osa, nested, "42")
+
+		entries, err := osa.ReadDir(current)
+		assert.NoError(t, err)
+		assert.Equal(t, []fsEntry{{"VERSION", false}}, castFsEntries(entries, false))
+
+		entries, err = osa.ReadDir(tmpDir)
+		assert.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, "current", entries[0].Name())
+		assert.Equal(t, osaPkg.ModeSymlink, entries[0].Type())
+
+		got, err := osa.Readlink(current)
+		assert.NoError(t, err)
+		assert.Equal(t, tos.Join("releases", "v42"), got)
+	})
+	t.Run("SymlinkDangling", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		target := tos.Join(tmpDir, "target")
+		link := tos.Join(tmpDir, "link")
+		require.NoError(t, osa.Symlink(target, link))
+
+		_, err := osa.Stat(link)
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+		_, err = osa.Lstat(link)
+		assert.NoError(t, err)
+
+		f, err := osa.OpenFile(link, osaPkg.O_WRONLY|osaPkg.O_CREATE, 0600)
+		require.NoError(t, err)
+		_, err = f.Write([]byte("some data"))
+		assert.NoError(t, err)
+		assert.NoError(t, f.Close())
+		tos.AssertFileData(t, osa, target, "some data")
+	})
+	t.Run("SymlinkRemove", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		dir := tos.Join(tmpDir, "dir")
+		tos.RequireMkdir(t, osa, dir)
+		tos.RequireEmptyWrite(t, osa, tos.Join(dir, "file"))
+		link := tos.Join(tmpDir, "link")
+		require.NoError(t, osa.Symlink(dir, link))
+
+		assert.NoError(t, osa.Remove(link))
+		_, err := osa.Lstat(link)
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+		tos.AssertExists(t, osa, tos.Join(dir, "file"))
+
+		require.NoError(t, osa.Symlink(dir, link))
+		assert.NoError(t, osa.RemoveAll(link))
+		_, err = osa.Lstat(link)
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+		tos.AssertExists(t, osa, tos.Join(dir, "file"))
+	})
+	t.Run("SymlinkRename", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		tos.RequireWrite(t, osa, tos.Join(tmpDir, "file"), "some data")
+		link := tos.Join(tmpDir, "link")
+		require.NoError(t, osa.Symlink("file", link))
+
+		moved := tos.Join(tmpDir, "moved")
+		assert.NoError(t, osa.Rename(link, moved))
+		got, err := osa.Readlink(moved)
+		assert.NoError(t, err)
+		assert.Equal(t, "file", got)
+		tos.AssertFileData(t, osa, moved, "some data")
+
+		dir := tos.Join(tmpDir, "dir")
+		tos.RequireMkdir(t, osa, dir)
+		dirLink := tos.Join(tmpDir, "dirlink")
+		require.NoError(t, osa.Symlink(dir, dirLink))
+		assert.Error(t, osa.Rename(dir, tos.Join(dirLink, "sub")))
+		tos.AssertExistsIsDir(t, osa, dir, true)
+
+		assert.NoError(t, osa.Rename(dirLink, tos.Join(dirLink, "link")))
+		got, err = osa.Readlink(tos.Join(dir, "link"))
+		assert.NoError(t, err)
+		assert.Equal(t, dir, got)
+	})
+	t.Run("SymlinkErrExists", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		file := tos.Join(tmpDir, "file")
+		tos.RequireEmptyWrite(t, osa, file)
+		link := tos.Join(tmpDir, "link")
+		require.NoError(t, osa.Symlink("missing", link))
+
+		err := osa.Symlink(file, file)
+		assert.True(t, osa.IsExist(err), "want exist error")
+		err = osa.Symlink(file, link)
+		assert.True(t, osa.IsExist(err), "want exist error")
+		err = osa.Mkdir(link, 0700)
+		assert.True(t, osa.IsExist(err), "want exist error")
+	})
+	t.Run("SymlinkLoop", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		a, b := tos.Join(tmpDir, "a"), tos.Join(tmpDir, "b")
+		require.NoError(t, osa.Symlink(b, a))
+		require.NoError(t, osa.Symlink(a, b))
+
+		_, err := osa.Stat(a)
+		assert.Error(t, err)
+		assert.False(t, osa.IsNotExist(err), "unexpected not-exist error")
+		_, err = osa.ReadFile(a)
+		assert.Error(t, err)
+		_, err = osa.Lstat(a)
+		assert.NoError(t, err)
+	})
+	t.Run("ReadlinkErr", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		file := tos.Join(tmpDir, "file")
+		tos.RequireEmptyWrite(t, osa, file)
+
+		_, err := osa.Readlink(file)
+		assert.Error(t, err)
+		_, err = osa.Readlink(tos.Join(tmpDir, "missing"))
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+	})
+
 	t.Run("Getwd", func(t *testing.T) {
 		workDir, err := osa.Getwd()
 		tos.AssertExists(t, osa, workDir)
 		assert.NoError(t, err)
 	})
+	t.Run("Chdir", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		orgWd, err := osa.Getwd()
+		require.NoError(t, err)
+		defer osa.Chdir(orgWd)
+
+		assert.NoError(t, osa.Chdir(tmpDir))
+		wd, err := osa.Getwd()
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Base(tmpDir), filepath.Base(wd))
+
+		tos.RequireMkdir(t, osa, "sub")
+		tos.AssertExists(t, osa, tos.Join(tmpDir, "sub"))
+
+		assert.NoError(t, osa.Chdir("sub"))
+		wd, err = osa.Getwd()
+		assert.NoError(t, err)
+		assert.Equal(t, "sub", filepath.Base(wd))
+
+		assert.NoError(t, osa.Chdir(".."))
+		wd, err = osa.Getwd()
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Base(tmpDir), filepath.Base(wd))
+	})
+	t.Run("ChdirErr", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		orgWd, err := osa.Getwd()
+		require.NoError(t, err)
+		defer osa.Chdir(orgWd)
+
+		err = osa.Chdir(tos.Join(tmpDir, "missing"))
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+
+		file := tos.Join(tmpDir, "file")
+		tos.RequireEmptyWrite(t, osa, file)
+		assert.Error(t, osa.Chdir(file))
+
+		wd, err := osa.Getwd()
+		assert.NoError(t, err)
+		assert.Equal(t, orgWd, wd)
+	})
+	t.Run("RelativePaths", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		orgWd, err := osa.Getwd()
+		require.NoError(t, err)
+		defer osa.Chdir(orgWd)
+		require.NoError(t, osa.Chdir(tmpDir))
+
+		tos.RequireWrite(t, osa, "file.txt", "some data")
+		tos.AssertFileData(t, osa, tos.Join(tmpDir, "file.txt"), "some data")
+
+		tos.RequireMkdirAll(t, osa, tos.Join("a", "b"))
+		tos.AssertExistsIsDir(t, osa, tos.Join(tmpDir, "a", "b"), true)
+
+		stat, err := osa.Stat(tos.Join("a", "..", "file.txt"))
+		assert.NoError(t, err)
+		if assert.NotNil(t, stat) {
+			assert.Equal(t, "file.txt", stat.Name())
+		}
+
+		entries, err := osa.ReadDir(".")
+		assert.NoError(t, err)
+		assert.Equal(t, []fsEntry{
+			{"a", true},
+			{"file.txt", false},
+		}, castFsEntries(entries, false))
+
+		f, err := osa.Open(tos.Join(".", "file.txt"))
+		require.NoError(t, err)
+		assert.NoError(t, f.Close())
+
+		f2, err := osa.OpenFile(tos.Join("a", "new.txt"), osaPkg.O_WRONLY|osaPkg.O_CREATE, 0600)
+		require.NoError(t, err)
+		assert.NoError(t, f2.Close())
+		tos.AssertExists(t, osa, tos.Join(tmpDir, "a", "new.txt"))
+
+		assert.NoError(t, osa.Rename("file.txt", tos.Join("a", "b", "moved.txt")))
+		tos.AssertNotExists(t, osa, tos.Join(tmpDir, "file.txt"))
+		tos.AssertFileData(t, osa, tos.Join(tmpDir, "a", "b", "moved.txt"), "some data")
+
+		assert.NoError(t, osa.Remove(tos.Join("a", "b", "moved.txt")))
+		tos.AssertNotExists(t, osa, tos.Join(tmpDir, "a", "b", "moved.txt"))
+
+		assert.NoError(t, osa.RemoveAll("a"))
+		tos.AssertNotExists(t, osa, tos.Join(tmpDir, "a"))
+		tos.AssertIsEmpty(t, osa, tmpDir)
+	})
+	t.Run("RelativePathsErrNotExist", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		orgWd, err := osa.Getwd()
+		require.NoError(t, err)
+		defer osa.Chdir(orgWd)
+		require.NoError(t, osa.Chdir(tmpDir))
+
+		_, err = osa.ReadFile("missing.txt")
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+		_, err = osa.Stat("")
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+		err = osa.Remove(tos.Join("missing", "file"))
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+		err = osa.Rename("missing.txt", "other.txt")
+		assert.True(t, osa.IsNotExist(err), "want not-exist error")
+	})
+	t.Run("RenameSamePath", func(t *testing.T) {
+		tmpDir := mkTempDir()
+		path := tos.Join(tmpDir, "file")
+		tos.RequireWrite(t, osa, path, "some data")
+
+		assert.NoError(t, osa.Rename(path, path))
+		tos.AssertFileData(t, osa, path, "some data")
+	})
 	t.Run("UserCacheDir", func(t *testing.T) {
 		cacheDir, err := osa.UserCacheDir()
 		tos.AssertExists(t, osa, cacheDir)
@@ -674,6 +1425,51 @@ func AssertOsa(
 		assert.NoError(t, err)
 	})
 
+	t.Run("Env", func(t *testing.T) {
+		key := "OSA_TEST_ENV_VAR"
+		otherKey := "OSA_TEST_ENV_OTHER"
+		require.NoError(t, osa.Unsetenv(key))
+		require.NoError(t, osa.Unsetenv(otherKey))
+		defer osa.Unsetenv(key)
+		defer osa.Unsetenv(otherKey)
+
+		got, ok := osa.LookupEnv(key)
+		assert.False(t, ok)
+		assert.Empty(t, got)
+
+		assert.NoError(t, osa.Setenv(key, "some value"))
+		assert.Equal(t, "some value", osa.Getenv(key))
+		got, ok = osa.LookupEnv(key)
+		assert.True(t, ok)
+		assert.Equal(t, "some value", got)
+		assert.Contains(t, osa.Environ(), key+"=some value")
+
+		assert.NoError(t, osa.Setenv(otherKey, ""))
+		got, ok = osa.LookupEnv(otherKey)
+		assert.True(t, ok, "want empty variable to be present")
+		assert.Empty(t, got)
+
+		assert.NoError(t, osa.Unsetenv(key))
+		assert.Empty(t, osa.Getenv(key))
+		_, ok = osa.LookupEnv(key)
+		assert.False(t, ok)
+		assert.NotContains(t, osa.Environ(), key+"=some value")
+	})
+	t.Run("SetenvErrInvalid", func(t *testing.T) {
+		assert.Error(t, osa.Setenv("", "value"))
+		assert.Error(t, osa.Setenv("OSA=TEST", "value"))
+	})
+	t.Run("ExpandEnv", func(t *testing.T) {
+		key := "OSA_TEST_ENV_EXPAND"
+		require.NoError(t, osa.Setenv(key, "world"))
+		defer osa.Unsetenv(key)
+
+		got := osa.ExpandEnv("hello $" + key + ", ${" + key + "}!")
+		assert.Equal(t, "hello world, world!", got)
+		got = osa.ExpandEnv("missing: '$OSA_TEST_ENV_MISSING'")
+		assert.Equal(t, "missing: ''", got)
+	})
+
 	t.Run("Exit", assertExit)
 
 	t.Run("Stdio", func(t *testing.T) {
@@ -1,6 +1,9 @@
 package osa
 
-import "io/fs"
+import (
+	"io/fs"
+	"os"
+)
 
 // A DirEntry is an entry read from a directory.
 type DirEntry = fs.DirEntry
@@ -13,3 +16,71 @@ type FileInfo = fs.FileInfo
 
 // PathError records an error and the operation and file path that caused it.
 type PathError = fs.PathError
+
+// LinkError records an error during a link or symlink or rename system call
+// and the paths that caused it.
+type LinkError = os.LinkError
+
+// File represents an open file descriptor as returned by OpenFile and Create.
+//
+// File is satisfied by *os.File.
+type File = interface {
+	// Read reads up to len(b) bytes from the File and stores them in b.
+	Read(b []byte) (n int, err error)
+	// ReadAt reads len(b) bytes from the File starting at byte offset off.
+	ReadAt(b []byte, off int64) (n int, err error)
+	// Write writes len(b) bytes from b to the File.
+	Write(b []byte) (n int, err error)
+	// WriteAt writes len(b) bytes to the File starting at byte offset off.
+	WriteAt(b []byte, off int64) (n int, err error)
+	// Seek sets the offset for the next Read or Write on file to offset.
+	Seek(offset int64, whence int) (ret int64, err error)
+	// Truncate changes the size of the file.
+	Truncate(size int64) error
+	// Sync commits the current contents of the file to stable storage.
+	Sync() error
+	// Close closes the File, rendering it unusable for I/O.
+	Close() error
+	// Name returns the name of the file as presented to Open.
+	Name() string
+	// Stat returns the FileInfo structure describing file.
+	Stat() (FileInfo, error)
+	// ReadDir reads the contents of the directory associated with the file.
+	ReadDir(n int) ([]DirEntry, error)
+}
+
+// Flags to OpenFile wrapping those of the underlying system.
+const (
+	// Exactly one of O_RDONLY, O_WRONLY, or O_RDWR must be specified.
+	O_RDONLY int = os.O_RDONLY // open the file read-only.
+	O_WRONLY int = os.O_WRONLY // open the file write-only.
+	O_RDWR   int = os.O_RDWR   // open the file read-write.
+	// The remaining values may be or'ed in to control behavior.
+	O_APPEND int = os.O_APPEND // append data to the file when writing.
+	O_CREATE int = os.O_CREATE // create a new file if none exists.
+	O_EXCL   int = os.O_EXCL   // used with O_CREATE, file must not exist.
+	O_SYNC   int = os.O_SYNC   // open for synchronous I/O.
+	O_TRUNC  int = os.O_TRUNC  // truncate regular writable file when opened.
+)
+
+// The defined file mode bits are the most significant bits of the FileMode.
+const (
+	ModeDir        = fs.ModeDir        // d: is a directory
+	ModeAppend     = fs.ModeAppend     // a: append-only
+	ModeExclusive  = fs.ModeExclusive  // l: exclusive use
+	ModeTemporary  = fs.ModeTemporary  // T: temporary file; Plan 9 only
+	ModeSymlink    = fs.ModeSymlink    // L: symbolic link
+	ModeDevice     = fs.ModeDevice     // D: device file
+	ModeNamedPipe  = fs.ModeNamedPipe  // p: named pipe (FIFO)
+	ModeSocket     = fs.ModeSocket     // S: Unix domain socket
+	ModeSetuid     = fs.ModeSetuid     // u: setuid
+	ModeSetgid     = fs.ModeSetgid     // g: setgid
+	ModeCharDevice = fs.ModeCharDevice // c: Unix character device, when ModeDevice is set
+	ModeSticky     = fs.ModeSticky     // t: sticky
+	ModeIrregular  = fs.ModeIrregular  // ?: non-regular file; nothing else is known about this file
+
+	// Mask for the type bits. For regular files, none will be set.
+	ModeType = fs.ModeType
+
+	ModePerm = fs.ModePerm // Unix permission bits, 0o777
+)
@@ -0,0 +1,125 @@
+package vcros
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Version is the version of the cassette format written by this package.
+const Version = 1
+
+// Cassette holds recorded calls.
+//
+// Cassettes are stored as indented JSON. The format is stable across
+// versions of this package; incompatible changes increment Version.
+type Cassette struct {
+	// Version is the version of the cassette format.
+	Version int `json:"version"`
+	// Interactions holds the recorded calls, in the order they completed.
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction describes a recorded call.
+type Interaction struct {
+	// Op is the name of the called method, e.g. "WriteFile" or "File.Write".
+	// See hookos.Call.
+	Op string `json:"op"`
+	// Paths holds the file paths the call operated on, if any.
+	Paths []string `json:"paths,omitempty"`
+	// Args holds the arguments of the call. Buffers that read calls read into
+	// are recorded by their length only.
+	Args []Value `json:"args,omitempty"`
+	// Results holds the results of the call, including the error, if any.
+	Results []Value `json:"results,omitempty"`
+	// Read holds the bytes read calls read into their buffer, if any.
+	Read *Value `json:"read,omitempty"`
+}
+
+// Value is an encoded argument or result.
+type Value struct {
+	// Type is the type of the value, e.g. "string", "int", or "error".
+	Type string `json:"type"`
+	// Data holds the JSON encoding of the value, if any.
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// ReadCassette reads a cassette from r.
+func ReadCassette(r io.Reader) (*Cassette, error) {
+	var c Cassette
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, err
+	}
+	if c.Version != Version {
+		return nil, fmt.Errorf("unsupported cassette version %d", c.Version)
+	}
+	for i := range c.Interactions {
+		in := &c.Interactions[i]
+		vals := append(append([]Value{}, in.Args...), in.Results...)
+		if in.Read != nil {
+			vals = append(vals, *in.Read)
+		}
+		if _, err := decodeValues(vals); err != nil {
+			return nil, fmt.Errorf("interaction %d (%s): %w", i, in.Op, err)
+		}
+		compactValues(in.Args)
+		compactValues(in.Results)
+		if in.Read != nil {
+			read := []Value{*in.Read}
+			compactValues(read)
+			in.Read = &read[0]
+		}
+	}
+	return &c, nil
+}
+
+// compactValues strips insignificant space from the data of vals, so that
+// values can be compared byte by byte.
+func compactValues(vals []Value) {
+	for i, v := range vals {
+		if len(v.Data) == 0 {
+			continue
+		}
+		var buf bytes.Buffer
+		if json.Compact(&buf, v.Data) == nil {
+			vals[i].Data = buf.Bytes()
+		}
+	}
+}
+
+// WriteTo writes the cassette to w.
+func (c *Cassette) WriteTo(w io.Writer) (int64, error) {
+	b, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(b, '\n'))
+	return int64(n), err
+}
+
+// Load reads a cassette from the named file.
+//
+// Load always uses the real file system, regardless of patches of the OS
+// abstraction.
+func Load(name string) (*Cassette, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCassette(f)
+}
+
+// Save writes the cassette to the named file, creating it if necessary.
+//
+// Save always uses the real file system, regardless of patches of the OS
+// abstraction.
+func (c *Cassette) Save(name string) error {
+	var buf bytes.Buffer
+	if _, err := c.WriteTo(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(name, buf.Bytes(), 0644)
+}
@@ -0,0 +1,58 @@
+package vcros
+
+import (
+	"io/fs"
+	"time"
+)
+
+// file is a replayed file. Its methods are never called, as all file methods
+// except Name are hooked and replayed.
+type file struct{ name string }
+
+func (f file) Read(b []byte) (int, error)               { return 0, ErrNotRecorded }
+func (f file) ReadAt(b []byte, off int64) (int, error)  { return 0, ErrNotRecorded }
+func (f file) Write(b []byte) (int, error)              { return 0, ErrNotRecorded }
+func (f file) WriteAt(b []byte, off int64) (int, error) { return 0, ErrNotRecorded }
+func (f file) Seek(off int64, whence int) (int64, error) {
+	return 0, ErrNotRecorded
+}
+func (f file) Truncate(size int64) error            { return ErrNotRecorded }
+func (f file) Sync() error                          { return ErrNotRecorded }
+func (f file) Close() error                         { return ErrNotRecorded }
+func (f file) Name() string                         { return f.name }
+func (f file) Stat() (fs.FileInfo, error)           { return nil, ErrNotRecorded }
+func (f file) ReadDir(n int) ([]fs.DirEntry, error) { return nil, ErrNotRecorded }
+
+// reader is a replayed stdio reader. See file.
+type reader struct{}
+
+func (reader) Read(b []byte) (int, error) { return 0, ErrNotRecorded }
+
+// writer is a replayed stdio writer. See file.
+type writer struct{}
+
+func (writer) Write(b []byte) (int, error) { return 0, ErrNotRecorded }
+
+// fileInfo is a replayed FileInfo.
+type fileInfo struct{ d fileInfoData }
+
+func (fi fileInfo) Name() string       { return fi.d.Name }
+func (fi fileInfo) Size() int64        { return fi.d.Size }
+func (fi fileInfo) Mode() fs.FileMode  { return fs.FileMode(fi.d.Mode) }
+func (fi fileInfo) ModTime() time.Time { return fi.d.ModTime }
+func (fi fileInfo) IsDir() bool        { return fi.Mode().IsDir() }
+func (fi fileInfo) Sys() interface{}   { return nil }
+
+// dirEntry is a replayed DirEntry.
+type dirEntry struct{ d dirEntryData }
+
+func (e dirEntry) Name() string      { return e.d.Name }
+func (e dirEntry) IsDir() bool       { return e.Type().IsDir() }
+func (e dirEntry) Type() fs.FileMode { return fs.FileMode(e.d.Type) }
+
+func (e dirEntry) Info() (fs.FileInfo, error) {
+	if e.d.Info == nil {
+		return nil, fs.ErrNotExist
+	}
+	return fileInfo{*e.d.Info}, nil
+}
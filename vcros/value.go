@@ -0,0 +1,412 @@
+package vcros
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/echocrow/osa/hookos"
+)
+
+// readOps lists methods that read into a buffer passed as their first
+// argument.
+var readOps = map[string]bool{
+	"File.Read":   true,
+	"File.ReadAt": true,
+	"Stdin.Read":  true,
+}
+
+func newValue(typ string, data interface{}) Value {
+	v := Value{Type: typ}
+	if data != nil {
+		v.Data, _ = json.Marshal(data)
+	}
+	return v
+}
+
+// encodeArgs encodes the arguments of c.
+func encodeArgs(c hookos.Call) []Value {
+	if len(c.Args) == 0 {
+		return nil
+	}
+	vals := make([]Value, len(c.Args))
+	for i, a := range c.Args {
+		if i == 0 && readOps[c.Op] {
+			b, _ := a.([]byte)
+			vals[i] = newValue("buffer", len(b))
+			continue
+		}
+		vals[i] = encode(a)
+	}
+	return vals
+}
+
+// encodeResults encodes the results of a call of op.
+func encodeResults(op string, res []interface{}) []Value {
+	if len(res) == 0 {
+		return nil
+	}
+	vals := make([]Value, len(res))
+	for i, r := range res {
+		switch {
+		case isNil(r):
+			vals[i] = newValue("nil", nil)
+		case op == "Stdin":
+			vals[i] = newValue("reader", nil)
+		case op == "Stdout", op == "Stderr":
+			vals[i] = newValue("writer", nil)
+		default:
+			vals[i] = encode(r)
+		}
+	}
+	return vals
+}
+
+// encode encodes a single argument or result.
+func encode(x interface{}) Value {
+	if isNil(x) {
+		return newValue("nil", nil)
+	}
+	switch x := x.(type) {
+	case error:
+		return newValue("error", encodeError(x))
+	case string:
+		return newValue("string", x)
+	case []string:
+		return newValue("strings", x)
+	case []byte:
+		if utf8.Valid(x) {
+			return newValue("text", string(x))
+		}
+		return newValue("bytes", x)
+	case int:
+		return newValue("int", x)
+	case int64:
+		return newValue("int64", x)
+	case uint8:
+		return newValue("uint8", x)
+	case bool:
+		return newValue("bool", x)
+	case fs.FileMode:
+		return newValue("mode", uint32(x))
+	case time.Time:
+		return newValue("time", x)
+	case fs.FileInfo:
+		return newValue("fileinfo", encodeFileInfo(x))
+	case []fs.DirEntry:
+		l := make([]dirEntryData, len(x))
+		for i, e := range x {
+			l[i] = dirEntryData{Name: e.Name(), Type: uint32(e.Type())}
+			if info, err := e.Info(); err == nil {
+				d := encodeFileInfo(info)
+				l[i].Info = &d
+			}
+		}
+		return newValue("direntries", l)
+	case interface{ Name() string }:
+		return newValue("file", x.Name())
+	case fs.File:
+		return newValue("file", "")
+	}
+	return newValue("unknown", fmt.Sprint(x))
+}
+
+func decodeValues(vals []Value) ([]interface{}, error) {
+	if len(vals) == 0 {
+		return nil, nil
+	}
+	l := make([]interface{}, len(vals))
+	for i, v := range vals {
+		x, err := decode(v)
+		if err != nil {
+			return nil, err
+		}
+		l[i] = x
+	}
+	return l, nil
+}
+
+// decode decodes a single argument or result.
+func decode(v Value) (interface{}, error) {
+	var err error
+	unmarshal := func(p interface{}) {
+		err = json.Unmarshal(v.Data, p)
+	}
+	var x interface{}
+	switch v.Type {
+	case "nil":
+	case "error":
+		var d *errorData
+		unmarshal(&d)
+		x = decodeError(d)
+	case "string":
+		var s string
+		unmarshal(&s)
+		x = s
+	case "strings":
+		var l []string
+		unmarshal(&l)
+		x = l
+	case "text":
+		var s string
+		unmarshal(&s)
+		x = []byte(s)
+	case "bytes":
+		var b []byte
+		unmarshal(&b)
+		if b == nil {
+			b = []byte{}
+		}
+		x = b
+	case "buffer":
+		var n int
+		unmarshal(&n)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid buffer length %d", n)
+		}
+		x = make([]byte, n)
+	case "int":
+		var n int
+		unmarshal(&n)
+		x = n
+	case "int64":
+		var n int64
+		unmarshal(&n)
+		x = n
+	case "uint8":
+		var n uint8
+		unmarshal(&n)
+		x = n
+	case "bool":
+		var b bool
+		unmarshal(&b)
+		x = b
+	case "mode":
+		var m uint32
+		unmarshal(&m)
+		x = fs.FileMode(m)
+	case "time":
+		var t time.Time
+		unmarshal(&t)
+		x = t
+	case "fileinfo":
+		var d fileInfoData
+		unmarshal(&d)
+		x = fileInfo{d}
+	case "direntries":
+		var l []dirEntryData
+		unmarshal(&l)
+		entries := make([]fs.DirEntry, len(l))
+		for i, d := range l {
+			entries[i] = dirEntry{d}
+		}
+		x = entries
+	case "file":
+		var name string
+		unmarshal(&name)
+		x = file{name}
+	case "reader":
+		x = reader{}
+	case "writer":
+		x = writer{}
+	default:
+		return nil, fmt.Errorf("unsupported value type %q", v.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value: %w", v.Type, err)
+	}
+	return x, nil
+}
+
+// isNil reports whether x is nil or holds a nil pointer, slice, or map.
+func isNil(x interface{}) bool {
+	if x == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(x); v.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface,
+		reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
+
+// errorData is the encoding of an error.
+//
+// Errors of the os package and syscall errnos are encoded by their structure
+// and decode to the same types. Other errors are encoded by their message and
+// the sentinel errors they match via errors.Is.
+type errorData struct {
+	Kind    string     `json:"kind"` // path, link, syscall, errno, sentinel, or text
+	Op      string     `json:"op,omitempty"`
+	Path    string     `json:"path,omitempty"`
+	Old     string     `json:"old,omitempty"`
+	New     string     `json:"new,omitempty"`
+	Syscall string     `json:"syscall,omitempty"`
+	Name    string     `json:"name,omitempty"`
+	Errno   int        `json:"errno,omitempty"`
+	Msg     string     `json:"msg,omitempty"`
+	Is      []string   `json:"is,omitempty"`
+	Err     *errorData `json:"err,omitempty"`
+}
+
+// sentinels lists well-known errors that are encoded by name.
+var sentinels = []struct {
+	name string
+	err  error
+}{
+	{"ErrInvalid", fs.ErrInvalid},
+	{"ErrPermission", fs.ErrPermission},
+	{"ErrExist", fs.ErrExist},
+	{"ErrNotExist", fs.ErrNotExist},
+	{"ErrClosed", fs.ErrClosed},
+	{"EOF", io.EOF},
+	{"ErrUnexpectedEOF", io.ErrUnexpectedEOF},
+	{"ErrShortWrite", io.ErrShortWrite},
+}
+
+// errnos lists errnos that are encoded by name, which is portable across
+// platforms.
+var errnos = []struct {
+	name  string
+	errno syscall.Errno
+}{
+	{"EPERM", syscall.EPERM},
+	{"ENOENT", syscall.ENOENT},
+	{"EIO", syscall.EIO},
+	{"EBADF", syscall.EBADF},
+	{"EAGAIN", syscall.EAGAIN},
+	{"EACCES", syscall.EACCES},
+	{"EBUSY", syscall.EBUSY},
+	{"EEXIST", syscall.EEXIST},
+	{"EXDEV", syscall.EXDEV},
+	{"ENOTDIR", syscall.ENOTDIR},
+	{"EISDIR", syscall.EISDIR},
+	{"EINVAL", syscall.EINVAL},
+	{"EMFILE", syscall.EMFILE},
+	{"EFBIG", syscall.EFBIG},
+	{"ENOSPC", syscall.ENOSPC},
+	{"EROFS", syscall.EROFS},
+	{"EMLINK", syscall.EMLINK},
+	{"ENAMETOOLONG", syscall.ENAMETOOLONG},
+	{"ENOTEMPTY", syscall.ENOTEMPTY},
+	{"ELOOP", syscall.ELOOP},
+	{"EDQUOT", syscall.EDQUOT},
+}
+
+func encodeError(err error) *errorData {
+	if err == nil {
+		return nil
+	}
+	switch e := err.(type) {
+	case *fs.PathError:
+		return &errorData{Kind: "path", Op: e.Op, Path: e.Path, Err: encodeError(e.Err)}
+	case *os.LinkError:
+		return &errorData{Kind: "link", Op: e.Op, Old: e.Old, New: e.New, Err: encodeError(e.Err)}
+	case *os.SyscallError:
+		return &errorData{Kind: "syscall", Syscall: e.Syscall, Err: encodeError(e.Err)}
+	case syscall.Errno:
+		for _, n := range errnos {
+			if e == n.errno {
+				return &errorData{Kind: "errno", Name: n.name}
+			}
+		}
+		return &errorData{Kind: "errno", Errno: int(e), Msg: e.Error()}
+	}
+	for _, s := range sentinels {
+		if err == s.err {
+			return &errorData{Kind: "sentinel", Name: s.name}
+		}
+	}
+	d := &errorData{Kind: "text", Msg: err.Error()}
+	for _, s := range sentinels {
+		if errors.Is(err, s.err) {
+			d.Is = append(d.Is, s.name)
+		}
+	}
+	return d
+}
+
+func decodeError(d *errorData) error {
+	if d == nil {
+		return nil
+	}
+	switch d.Kind {
+	case "path":
+		return &fs.PathError{Op: d.Op, Path: d.Path, Err: decodeError(d.Err)}
+	case "link":
+		return &os.LinkError{Op: d.Op, Old: d.Old, New: d.New, Err: decodeError(d.Err)}
+	case "syscall":
+		return &os.SyscallError{Syscall: d.Syscall, Err: decodeError(d.Err)}
+	case "errno":
+		for _, n := range errnos {
+			if d.Name == n.name {
+				return n.errno
+			}
+		}
+		return syscall.Errno(d.Errno)
+	case "sentinel":
+		for _, s := range sentinels {
+			if d.Name == s.name {
+				return s.err
+			}
+		}
+	}
+	e := &textError{msg: d.Msg}
+	for _, name := range d.Is {
+		for _, s := range sentinels {
+			if name == s.name {
+				e.is = append(e.is, s.err)
+			}
+		}
+	}
+	return e
+}
+
+// textError is a replayed error of a type that is not encoded structurally.
+type textError struct {
+	msg string
+	is  []error
+}
+
+func (e *textError) Error() string { return e.msg }
+
+func (e *textError) Is(target error) bool {
+	for _, err := range e.is {
+		if err == target {
+			return true
+		}
+	}
+	return false
+}
+
+// fileInfoData is the encoding of a FileInfo.
+type fileInfoData struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	Mode    uint32    `json:"mode"`
+	ModTime time.Time `json:"modTime"`
+}
+
+func encodeFileInfo(fi fs.FileInfo) fileInfoData {
+	return fileInfoData{
+		Name:    fi.Name(),
+		Size:    fi.Size(),
+		Mode:    uint32(fi.Mode()),
+		ModTime: fi.ModTime(),
+	}
+}
+
+// dirEntryData is the encoding of a DirEntry.
+type dirEntryData struct {
+	Name string        `json:"name"`
+	Type uint32        `json:"type"`
+	Info *fileInfoData `json:"info,omitempty"`
+}
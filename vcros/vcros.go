@@ -0,0 +1,234 @@
+// Package vcros provides an OS abstraction implementation that records calls
+// to another implementation into a cassette, and replays them later without
+// calling any implementation.
+//
+// A test typically records its calls once against the real OS, and replays
+// them deterministically afterwards:
+//
+//	path := "testdata/cassette.json"
+//	mode, c := vcros.Replay, (*vcros.Cassette)(nil)
+//	if *record {
+//		mode = vcros.Record
+//	} else if c, err = vcros.Load(path); err != nil {
+//		t.Fatal(err)
+//	}
+//	v := vcros.New(oos.New(), c, mode)
+//	testos.Patch(t, v)
+//	// ...
+//	if mode == vcros.Record {
+//		err = v.Cassette().Save(path)
+//	} else {
+//		err = v.Err()
+//	}
+//
+// Replayed calls must match recorded calls by op, paths, and arguments. Calls
+// are matched in the order they were recorded, so concurrent calls may be
+// replayed in a different order than recorded, but each recorded call is
+// replayed at most once.
+package vcros
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/hookos"
+)
+
+// Mode selects whether a vcros instance records or replays calls.
+type Mode int
+
+const (
+	// Record passes calls to the wrapped implementation and records them.
+	Record Mode = iota
+	// Replay returns the results of recorded calls without calling the
+	// wrapped implementation.
+	Replay
+)
+
+// ErrNotRecorded is reported by replayed calls that are not in the cassette.
+var ErrNotRecorded = errors.New("call not recorded")
+
+type vcros struct {
+	osa.I
+	d *deck
+}
+
+// New creates a new vcros instance in the given mode.
+//
+// In record mode, calls are passed to o and appended to c. In replay mode,
+// calls are answered from c, and o is never called and may be nil. A nil c
+// is treated as an empty cassette.
+//
+// Replayed calls that are not in the cassette fail with ErrNotRecorded, or
+// return zero values if their method does not return an error. See Err.
+func New(o osa.I, c *Cassette, mode Mode) vcros {
+	if c == nil {
+		c = &Cassette{Version: Version}
+	}
+	d := &deck{mode: mode, c: c, used: make([]bool, len(c.Interactions))}
+	return vcros{hookos.New(o, d.hook), d}
+}
+
+// Cassette returns a copy of the cassette of the vcros instance, including
+// all calls recorded so far.
+func (v vcros) Cassette() *Cassette {
+	v.d.mu.Lock()
+	defer v.d.mu.Unlock()
+	c := *v.d.c
+	c.Interactions = append([]Interaction{}, c.Interactions...)
+	return &c
+}
+
+// Err returns an error listing all replayed calls that were not in the
+// cassette, or nil if there were none.
+func (v vcros) Err() error {
+	v.d.mu.Lock()
+	defer v.d.mu.Unlock()
+	if len(v.d.unmatched) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrNotRecorded, strings.Join(v.d.unmatched, ", "))
+}
+
+// Unused returns all recorded calls that were not replayed yet.
+func (v vcros) Unused() []Interaction {
+	v.d.mu.Lock()
+	defer v.d.mu.Unlock()
+	var unused []Interaction
+	for i, in := range v.d.c.Interactions {
+		if !v.d.used[i] {
+			unused = append(unused, in)
+		}
+	}
+	return unused
+}
+
+// deck holds the state of a vcros instance.
+type deck struct {
+	mu        sync.Mutex
+	mode      Mode
+	c         *Cassette
+	used      []bool
+	unmatched []string
+}
+
+func (d *deck) hook(c hookos.Call, next hookos.Next) []interface{} {
+	if d.mode == Replay {
+		return d.replay(c)
+	}
+	return d.record(c, next)
+}
+
+func (d *deck) record(c hookos.Call, next hookos.Next) []interface{} {
+	args := encodeArgs(c)
+	res := next(c)
+	in := Interaction{
+		Op:      c.Op,
+		Paths:   c.Paths,
+		Args:    args,
+		Results: encodeResults(c.Op, res),
+	}
+	if readOps[c.Op] {
+		b, _ := c.Args[0].([]byte)
+		if n, _ := res[0].(int); n > 0 && n <= len(b) {
+			v := encode(b[:n])
+			in.Read = &v
+		}
+	}
+	d.mu.Lock()
+	d.c.Interactions = append(d.c.Interactions, in)
+	d.used = append(d.used, false)
+	d.mu.Unlock()
+	return res
+}
+
+func (d *deck) replay(c hookos.Call) []interface{} {
+	in, ok := d.match(c)
+	if !ok {
+		return notRecorded(c)
+	}
+	res, _ := decodeValues(in.Results)
+	if in.Read != nil {
+		b, _ := c.Args[0].([]byte)
+		data, _ := decode(*in.Read)
+		copy(b, data.([]byte))
+	}
+	return res
+}
+
+// match finds and claims the first unused interaction matching c.
+func (d *deck) match(c hookos.Call) (Interaction, bool) {
+	args := encodeArgs(c)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, in := range d.c.Interactions {
+		if d.used[i] || in.Op != c.Op {
+			continue
+		}
+		if equalStrings(in.Paths, c.Paths) && equalValues(in.Args, args) {
+			d.used[i] = true
+			return in, true
+		}
+	}
+	desc := c.Op
+	if len(c.Paths) > 0 {
+		desc += " " + strings.Join(c.Paths, " ")
+	}
+	d.unmatched = append(d.unmatched, desc)
+	return Interaction{}, false
+}
+
+// notRecorded returns results for a call that is not in the cassette.
+func notRecorded(c hookos.Call) []interface{} {
+	err := fmt.Errorf("%s: %w", c.Op, ErrNotRecorded)
+	if res, ok := hookos.Fail(c, err); ok {
+		return res
+	}
+	return append([]interface{}{}, plainResults[c.Op]...)
+}
+
+// plainResults maps methods that do not return an error to zero results.
+var plainResults = map[string][]interface{}{
+	"IsExist":         {false},
+	"IsNotExist":      {false},
+	"IsPermission":    {false},
+	"PathSeparator":   {uint8(0)},
+	"IsPathSeparator": {false},
+	"SameFile":        {false},
+	"Exit":            {},
+	"Getenv":          {""},
+	"LookupEnv":       {"", false},
+	"Environ":         {[]string(nil)},
+	"ExpandEnv":       {""},
+	"Stdin":           {reader{}},
+	"Stdout":          {writer{}},
+	"Stderr":          {writer{}},
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalValues(a, b []Value) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Type != b[i].Type || !bytes.Equal(a[i].Data, b[i].Data) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,193 @@
+package vcros_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/faultos"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vcros"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// workload issues various calls and logs their results.
+func workload(o osa.I, dir string) string {
+	var log strings.Builder
+	logf := func(format string, a ...interface{}) {
+		fmt.Fprintf(&log, format+"\n", a...)
+	}
+	file := filepath.Join(dir, "file")
+
+	logf("write: %v", o.WriteFile(file, []byte("data\x00\xff"), 0644))
+	data, err := o.ReadFile(file)
+	logf("read: %q %v", data, err)
+
+	_, err = o.ReadFile(filepath.Join(dir, "missing"))
+	logf("missing: %v %v %v", err, o.IsNotExist(err), errors.Is(err, fs.ErrNotExist))
+
+	fi, err := o.Stat(file)
+	logf("stat: %s %d %s %v", fi.Name(), fi.Size(), fi.Mode(), err)
+
+	f, err := o.OpenFile(file, osa.O_RDWR, 0)
+	logf("open: %s %v", f.Name(), err)
+	buf := make([]byte, 3)
+	n, err := f.Read(buf)
+	logf("file read: %d %q %v", n, buf, err)
+	off, err := f.Seek(0, io.SeekEnd)
+	logf("seek: %d %v", off, err)
+	n, err = f.Write([]byte("!"))
+	logf("file write: %d %v", n, err)
+	logf("close: %v", f.Close())
+
+	entries, err := o.ReadDir(dir)
+	logf("readdir: %d %v", len(entries), err)
+	for _, e := range entries {
+		info, err := e.Info()
+		logf("entry: %s %s %d %v", e.Name(), e.Type(), info.Size(), err)
+	}
+
+	logf("rename: %v", o.Rename(filepath.Join(dir, "missing"), file))
+	logf("env: %q", o.Getenv("KEY"))
+
+	fmt.Fprint(o.Stdout(), "out")
+	in, err := io.ReadAll(o.Stdin())
+	logf("stdin: %q %v", in, err)
+
+	return log.String()
+}
+
+func newVOS(t *testing.T) (osa.I, string) {
+	v := vos.New()
+	require.NoError(t, v.Setenv("KEY", "val"))
+	stdin, _, _ := vos.GetStdio(v)
+	_, err := stdin.Write([]byte("in"))
+	require.NoError(t, err)
+	return v, vos.MkTempDir(v)
+}
+
+func record(t *testing.T) (*vcros.Cassette, string, string) {
+	o, dir := newVOS(t)
+	r := vcros.New(o, nil, vcros.Record)
+	log := workload(r, dir)
+	return r.Cassette(), dir, log
+}
+
+func TestReplay(t *testing.T) {
+	c, dir, want := record(t)
+	assert.Contains(t, want, "missing: open")
+
+	r := vcros.New(nil, c, vcros.Replay)
+	got := workload(r, dir)
+	assert.Equal(t, want, got)
+	assert.NoError(t, r.Err())
+	assert.Empty(t, r.Unused())
+}
+
+func TestReplayFromDisk(t *testing.T) {
+	c, dir, want := record(t)
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	require.NoError(t, c.Save(path))
+	loaded, err := vcros.Load(path)
+	require.NoError(t, err)
+
+	r := vcros.New(nil, loaded, vcros.Replay)
+	assert.Equal(t, want, workload(r, dir))
+	assert.NoError(t, r.Err())
+
+	var a, b bytes.Buffer
+	_, err = c.WriteTo(&a)
+	require.NoError(t, err)
+	_, err = loaded.WriteTo(&b)
+	require.NoError(t, err)
+	assert.Equal(t, a.String(), b.String())
+}
+
+func TestNotRecorded(t *testing.T) {
+	c, dir, _ := record(t)
+	r := vcros.New(nil, c, vcros.Replay)
+
+	_, err := r.ReadFile(filepath.Join(dir, "other"))
+	assert.ErrorIs(t, err, vcros.ErrNotRecorded)
+	assert.Equal(t, "", r.Getenv("OTHER"))
+	n, err := r.Stderr().Write([]byte("x"))
+	assert.Zero(t, n)
+	assert.ErrorIs(t, err, vcros.ErrNotRecorded)
+
+	err = r.Err()
+	assert.ErrorIs(t, err, vcros.ErrNotRecorded)
+	assert.Contains(t, err.Error(), "ReadFile "+filepath.Join(dir, "other"))
+	assert.Contains(t, err.Error(), "Getenv")
+	assert.Contains(t, err.Error(), "Stderr.Write")
+	assert.Len(t, r.Unused(), len(c.Interactions))
+}
+
+func TestArgsMismatch(t *testing.T) {
+	c, dir, _ := record(t)
+	r := vcros.New(nil, c, vcros.Replay)
+
+	file := filepath.Join(dir, "file")
+	err := r.WriteFile(file, []byte("other"), 0644)
+	assert.ErrorIs(t, err, vcros.ErrNotRecorded)
+	testos.AssertFileData(t, r, file, "data\x00\xff")
+}
+
+func TestReadCassette(t *testing.T) {
+	for name, data := range map[string]string{
+		"version": `{"version": 2, "interactions": []}`,
+		"type": `{"version": 1, "interactions": [
+			{"op": "Getenv", "results": [{"type": "float"}]}
+		]}`,
+		"data": `{"version": 1, "interactions": [
+			{"op": "Getenv", "results": [{"type": "string", "data": 1}]}
+		]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := vcros.ReadCassette(strings.NewReader(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestReplayErrno(t *testing.T) {
+	o, dir := newVOS(t)
+	file := filepath.Join(dir, "file")
+	f := faultos.New(o, faultos.Rule{Op: "WriteFile", Err: syscall.ENOSPC})
+	r := vcros.New(f, nil, vcros.Record)
+	want := r.WriteFile(file, nil, 0644)
+	require.ErrorIs(t, want, syscall.ENOSPC)
+
+	var buf bytes.Buffer
+	_, err := r.Cassette().WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), `"name": "ENOSPC"`)
+	c, err := vcros.ReadCassette(&buf)
+	require.NoError(t, err)
+
+	r = vcros.New(nil, c, vcros.Replay)
+	got := r.WriteFile(file, nil, 0644)
+	assert.Equal(t, want, got)
+}
+
+func TestRecordStdio(t *testing.T) {
+	o, _ := newVOS(t)
+	r := vcros.New(o, nil, vcros.Record)
+	fmt.Fprint(r.Stdout(), "out")
+
+	c := r.Cassette()
+	require.Len(t, c.Interactions, 2)
+	assert.Equal(t, "Stdout", c.Interactions[0].Op)
+	in := c.Interactions[1]
+	assert.Equal(t, "Stdout.Write", in.Op)
+	assert.Equal(t, []vcros.Value{{Type: "text", Data: []byte(`"out"`)}}, in.Args)
+}
@@ -0,0 +1,65 @@
+package vos
+
+import (
+	"sync"
+	"time"
+)
+
+// WithClock sets the clock used for file timestamps of the vos instance.
+//
+// The clock defaults to time.Now. Use a *FakeClock for deterministic
+// timestamps.
+func WithClock(now func() time.Time) Option {
+	return func(c *config) {
+		c.now = now
+	}
+}
+
+// FakeClock is a manually controlled clock.
+//
+// Pass its Now method to WithClock to get deterministic file timestamps.
+// A FakeClock is safe for concurrent use.
+type FakeClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// NewFakeClock returns a FakeClock set to a given time.
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{now: t}
+}
+
+// Now returns the current time of the clock.
+//
+// If an auto-step is set, the clock advances by the step after each call.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// Set sets the current time of the clock.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// Advance moves the clock forward by a given duration.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// AutoStep makes the clock advance by a given duration after each reading.
+//
+// A step of 0 disables auto-stepping.
+func (c *FakeClock) AutoStep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.step = d
+}
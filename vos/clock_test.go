@@ -0,0 +1,100 @@
+package vos_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClock(t *testing.T) {
+	t0 := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := vos.NewFakeClock(t0)
+	assert.Equal(t, t0, c.Now())
+	assert.Equal(t, t0, c.Now())
+
+	c.Advance(time.Hour)
+	assert.Equal(t, t0.Add(time.Hour), c.Now())
+
+	t1 := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.Set(t1)
+	assert.Equal(t, t1, c.Now())
+
+	c.AutoStep(time.Second)
+	assert.Equal(t, t1, c.Now())
+	assert.Equal(t, t1.Add(time.Second), c.Now())
+	c.AutoStep(0)
+	assert.Equal(t, t1.Add(2*time.Second), c.Now())
+	assert.Equal(t, t1.Add(2*time.Second), c.Now())
+}
+
+func TestWithClock(t *testing.T) {
+	t0 := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := vos.NewFakeClock(t0)
+	v := vos.New(vos.WithClock(c.Now))
+
+	assertTimes := func(t *testing.T, path string, mtime, atime, ctime time.Time) {
+		t.Helper()
+		stat, err := v.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, mtime, stat.ModTime(), "mtime")
+		sys, ok := stat.Sys().(*vos.FileStat)
+		require.True(t, ok)
+		assert.Equal(t, atime, sys.Atime, "atime")
+		assert.Equal(t, ctime, sys.Ctime, "ctime")
+	}
+
+	tmpDir := vos.MkTempDir(v)
+	assertTimes(t, tmpDir, t0, t0, t0)
+
+	t1 := t0.Add(time.Minute)
+	c.Set(t1)
+	file := testos.Join(tmpDir, "file")
+	testos.RequireWrite(t, v, file, "some data")
+	assertTimes(t, file, t1, t1, t1)
+	assertTimes(t, tmpDir, t1, t0, t1)
+
+	t2 := t1.Add(time.Minute)
+	c.Set(t2)
+	f, err := v.OpenFile(file, osa.O_RDWR, 0)
+	require.NoError(t, err)
+	_, err = f.Read(make([]byte, 4))
+	require.NoError(t, err)
+	assertTimes(t, file, t1, t2, t1)
+
+	t3 := t2.Add(time.Minute)
+	c.Set(t3)
+	_, err = f.Write([]byte("more"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assertTimes(t, file, t3, t2, t3)
+
+	t4 := t3.Add(time.Minute)
+	c.Set(t4)
+	require.NoError(t, v.Chmod(file, 0644))
+	assertTimes(t, file, t3, t2, t4)
+
+	t5 := t4.Add(time.Minute)
+	c.Set(t5)
+	moved := testos.Join(tmpDir, "moved")
+	require.NoError(t, v.Rename(file, moved))
+	assertTimes(t, moved, t3, t2, t5)
+	assertTimes(t, tmpDir, t5, t0, t5)
+
+	t6 := t5.Add(time.Minute)
+	c.Set(t6)
+	past := t0.Add(-time.Hour)
+	require.NoError(t, v.Chtimes(moved, past, past.Add(time.Second)))
+	assertTimes(t, moved, past.Add(time.Second), past, t6)
+	require.NoError(t, v.Chtimes(moved, time.Time{}, past))
+	assertTimes(t, moved, past, past, t6)
+
+	t7 := t6.Add(time.Minute)
+	c.Set(t7)
+	require.NoError(t, v.Remove(moved))
+	assertTimes(t, tmpDir, t7, t0, t7)
+}
@@ -0,0 +1,161 @@
+package vos_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const workers = 16
+
+func runParallel(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestConcurrentRename(t *testing.T) {
+	v := vos.New()
+	tmpDir := vos.MkTempDir(v)
+	src := testos.Join(tmpDir, "src")
+	require.NoError(t, v.WriteFile(src, []byte("data"), 0644))
+
+	var ok int32
+	runParallel(workers, func(i int) {
+		dst := testos.Join(tmpDir, fmt.Sprint("dst", i))
+		if err := v.Rename(src, dst); err == nil {
+			atomic.AddInt32(&ok, 1)
+		} else {
+			assert.True(t, v.IsNotExist(err), err)
+		}
+	})
+	assert.Equal(t, int32(1), ok)
+
+	entries, err := v.ReadDir(tmpDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	testos.AssertFileData(t, v, testos.Join(tmpDir, entries[0].Name()), "data")
+}
+
+func TestConcurrentRemove(t *testing.T) {
+	v := vos.New()
+	tmpDir := vos.MkTempDir(v)
+	file := testos.Join(tmpDir, "file")
+	require.NoError(t, v.WriteFile(file, nil, 0644))
+
+	var ok int32
+	runParallel(workers, func(i int) {
+		if err := v.Remove(file); err == nil {
+			atomic.AddInt32(&ok, 1)
+		} else {
+			assert.True(t, v.IsNotExist(err), err)
+		}
+	})
+	assert.Equal(t, int32(1), ok)
+	_, err := v.Stat(file)
+	assert.True(t, v.IsNotExist(err))
+}
+
+func TestConcurrentWriteFile(t *testing.T) {
+	v := vos.New()
+	tmpDir := vos.MkTempDir(v)
+	file := testos.Join(tmpDir, "file")
+	require.NoError(t, v.WriteFile(file, []byte(strings.Repeat("-", 64)), 0644))
+
+	contents := make(map[string]bool, workers)
+	for i := 0; i < workers; i++ {
+		contents[strings.Repeat(fmt.Sprint(i%10), 64)] = true
+	}
+	contents[strings.Repeat("-", 64)] = true
+
+	runParallel(workers*2, func(i int) {
+		if i%2 == 0 {
+			data := strings.Repeat(fmt.Sprint(i/2%10), 64)
+			assert.NoError(t, v.WriteFile(file, []byte(data), 0644))
+			return
+		}
+		data, err := v.ReadFile(file)
+		if assert.NoError(t, err) {
+			assert.True(t, contents[string(data)], "torn read: %q", data)
+		}
+	})
+}
+
+func TestConcurrentAppend(t *testing.T) {
+	v := vos.New()
+	tmpDir := vos.MkTempDir(v)
+	file := testos.Join(tmpDir, "file")
+
+	const writes = 32
+	runParallel(workers, func(i int) {
+		f, err := v.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		require.NoError(t, err)
+		defer f.Close()
+		for j := 0; j < writes; j++ {
+			_, err := f.Write([]byte("ab"))
+			assert.NoError(t, err)
+		}
+	})
+
+	data, err := v.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte("ab"), workers*writes), data)
+}
+
+func TestConcurrentTree(t *testing.T) {
+	v := vos.New()
+	tmpDir := vos.MkTempDir(v)
+
+	runParallel(workers, func(i int) {
+		dir := testos.Join(tmpDir, "a", "b", fmt.Sprint(i))
+		assert.NoError(t, v.MkdirAll(dir, 0755))
+		file := testos.Join(dir, "file")
+		assert.NoError(t, v.WriteFile(file, []byte("x"), 0644))
+		_, err := v.ReadDir(testos.Join(tmpDir, "a", "b"))
+		assert.NoError(t, err)
+		_, err = v.Stat(file)
+		assert.NoError(t, err)
+		assert.NoError(t, v.Chmod(file, 0600))
+		if i%2 == 0 {
+			assert.NoError(t, v.RemoveAll(dir))
+		}
+	})
+
+	entries, err := v.ReadDir(testos.Join(tmpDir, "a", "b"))
+	require.NoError(t, err)
+	assert.Len(t, entries, workers/2)
+}
+
+func TestConcurrentEnvAndStdio(t *testing.T) {
+	v := vos.New()
+	_, stdout, _ := vos.GetStdio(v)
+
+	runParallel(workers, func(i int) {
+		key := fmt.Sprint("KEY", i)
+		assert.NoError(t, v.Setenv(key, "val"))
+		assert.Equal(t, "val", v.Getenv(key))
+		v.Environ()
+		fmt.Fprint(v.Stdout(), "x")
+	})
+
+	assert.Len(t, v.Environ(), workers)
+	out := new(bytes.Buffer)
+	_, err := out.ReadFrom(stdout)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("x", workers), out.String())
+}
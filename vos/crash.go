@@ -0,0 +1,221 @@
+package vos
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	os "github.com/echocrow/osa"
+	"github.com/echocrow/osa/hookos"
+)
+
+// durableState holds the state of a directory entry as of its last sync.
+type durableState struct {
+	meta    entryMeta
+	data    []byte
+	entries dirEntries
+}
+
+// sync makes the current state of a directory entry durable.
+//
+// For files, this covers the file data. For directories, this covers the
+// directory entries, but not the contents of the entries themselves.
+func (v *vfs) sync(e dirEntry) {
+	v.unshareMaps()
+	d := &durableState{meta: *e.meta()}
+	switch e := e.(type) {
+	case *vFile:
+		d.data = append([]byte{}, e.data...)
+	case *vDir:
+		d.entries = make(dirEntries, len(e.dirEntries))
+		for name, c := range e.dirEntries {
+			d.entries[name] = c
+		}
+	}
+	v.durable[e] = d
+}
+
+// syncAll makes the current state of all entries durable.
+func (v *vfs) syncAll() {
+	seen := make(map[dirEntry]bool)
+	var walk func(e dirEntry)
+	walk = func(e dirEntry) {
+		if seen[e] {
+			return
+		}
+		seen[e] = true
+		v.sync(e)
+		if dir, ok := e.(*vDir); ok {
+			for _, c := range dir.dirEntries {
+				walk(v.latest(c))
+			}
+		}
+	}
+	walk(v.root())
+}
+
+// crash reverts all entries to their durable state and invalidates all open
+// handles.
+func (v *vfs) crash() {
+	v.boot++
+	v.restore(v.root(), make(map[dirEntry]bool))
+	v.dropUsage()
+	v.durable = make(map[dirEntry]*durableState)
+	v.syncAll()
+	v.pwd = v.home
+}
+
+// restore reverts an entry and its durable children to their durable state.
+// The entry must be owned by the file system (see own).
+//
+// Entries that were never synced lose their contents. Directories listed by
+// multiple durable parents are kept in the first parent only.
+func (v *vfs) restore(e dirEntry, seen map[dirEntry]bool) {
+	if seen[e] {
+		e.meta().nlink++
+		return
+	}
+	seen[e] = true
+	d := v.durable[e]
+	m := e.meta()
+	if d != nil {
+		gen := m.gen
+		*m = d.meta
+		m.gen = gen
+	}
+	m.nlink = 1
+	switch e := e.(type) {
+	case *vFile:
+		e.data, e.shared = []byte{}, false
+		if d != nil {
+			e.data = append(e.data, d.data...)
+		}
+	case *vDir:
+		e.dirEntries = make(dirEntries)
+		if d == nil {
+			return
+		}
+		names := make([]string, 0, len(d.entries))
+		for name := range d.entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			c := v.own(d.entries[name])
+			if c.isDir() && seen[c] {
+				continue
+			}
+			e.dirEntries[name] = c
+			v.restore(c, seen)
+		}
+	}
+}
+
+// Sync makes the entire current state of the vos instance durable, similar to
+// sync(2).
+//
+// File data only becomes durable when its file is synced via File.Sync, and
+// directory entries only become durable when their directory is synced by
+// opening it and calling File.Sync. Sync makes everything durable at once,
+// e.g. to persist a test setup.
+func Sync(v vos) {
+	v.vfs.mu.Lock()
+	defer v.vfs.mu.Unlock()
+	v.syncAll()
+}
+
+// Crash simulates a power loss of the vos instance, reverting its file system
+// to its last durable state.
+//
+// Files and directories that were never synced lose their contents, and
+// directory entries that were never synced disappear. All open files are
+// closed, and the working directory is reset to the user home directory.
+func Crash(v vos) {
+	v.vfs.mu.Lock()
+	defer v.vfs.mu.Unlock()
+	v.crash()
+}
+
+// CrashPoint describes a point at which CrashPoints crashed a workload.
+type CrashPoint struct {
+	// N is the number of calls completed before the crash.
+	N int
+	// Op is the name of the last completed call, if any.
+	Op string
+	// Paths holds the file paths of the last completed call, if any.
+	Paths []string
+}
+
+func (p CrashPoint) String() string {
+	if p.N == 0 {
+		return "crash before first call"
+	}
+	s := fmt.Sprintf("crash after call %d: %s", p.N, p.Op)
+	if len(p.Paths) > 0 {
+		s += " " + strings.Join(p.Paths, " ")
+	}
+	return s
+}
+
+// crashSignal aborts a workload at a crash point.
+type crashSignal struct{}
+
+// CrashPoints runs workload once for every point at which it may crash, and
+// calls check with the crashed vos instance for each. It returns the number
+// of crash points.
+//
+// For each crash point, a new vos instance is created with opts, prepared via
+// setup, and its state is made durable (see Sync). workload then runs against
+// the instance, which crashes (see Crash) after a number of calls, aborting
+// the workload. The first run crashes before any calls, the last run crashes
+// after all calls.
+//
+// workload must be deterministic, and may only call the given OS abstraction
+// from a single goroutine.
+func CrashPoints(
+	setup func(o os.I),
+	workload func(o os.I),
+	check func(o os.I, p CrashPoint),
+	opts ...Option,
+) int {
+	n := -1
+	for i := 0; n < 0 || i <= n; i++ {
+		v := New(opts...)
+		if setup != nil {
+			setup(v)
+		}
+		Sync(v)
+		var p CrashPoint
+		if runUntil(v, workload, i, &p) {
+			n = p.N
+		}
+		Crash(v)
+		check(v, p)
+	}
+	return n + 1
+}
+
+// runUntil runs workload until it completed a number of calls, tracking the
+// last completed call in p. It reports whether workload completed.
+func runUntil(v vos, workload func(o os.I), calls int, p *CrashPoint) (
+	completed bool,
+) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(crashSignal); !ok {
+				panic(r)
+			}
+		}
+	}()
+	o := hookos.New(v, func(c hookos.Call, next hookos.Next) []interface{} {
+		if p.N >= calls {
+			panic(crashSignal{})
+		}
+		res := next(c)
+		p.N++
+		p.Op, p.Paths = c.Op, c.Paths
+		return res
+	})
+	workload(o)
+	return true
+}
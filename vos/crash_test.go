@@ -0,0 +1,135 @@
+package vos_test
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func syncDir(t *testing.T, o osa.I, dir string) {
+	d, err := o.Open(dir)
+	require.NoError(t, err)
+	defer d.Close()
+	require.NoError(t, d.(osa.File).Sync())
+}
+
+func syncFile(t *testing.T, o osa.I, name string) {
+	f, err := o.OpenFile(name, osa.O_RDONLY, 0)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, f.Sync())
+}
+
+func TestCrash(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	vos.Sync(v)
+
+	unsynced := testos.Join(dir, "unsynced")
+	dataOnly := testos.Join(dir, "data-only")
+	entryOnly := testos.Join(dir, "entry-only")
+	durable := testos.Join(dir, "durable")
+	for _, name := range []string{unsynced, dataOnly, entryOnly, durable} {
+		testos.RequireWrite(t, v, name, "data")
+	}
+	syncFile(t, v, dataOnly)
+	syncFile(t, v, durable)
+	syncDir(t, v, dir)
+	require.NoError(t, v.Remove(dataOnly))
+	testos.RequireWrite(t, v, unsynced, "lost")
+
+	vos.Crash(v)
+
+	testos.AssertFileData(t, v, durable, "data")
+	testos.AssertFileData(t, v, entryOnly, "")
+	testos.AssertFileData(t, v, dataOnly, "data")
+	testos.AssertFileData(t, v, unsynced, "")
+}
+
+func TestCrashUnsyncedDir(t *testing.T) {
+	v := vos.New()
+	tmpDir := vos.MkTempDir(v)
+	vos.Sync(v)
+
+	dir := testos.Join(tmpDir, "dir")
+	testos.RequireMkdir(t, v, dir)
+	syncDir(t, v, dir)
+	testos.RequireMkdir(t, v, testos.Join(dir, "sub"))
+	file := testos.Join(tmpDir, "file")
+	testos.RequireWrite(t, v, file, "data")
+	syncFile(t, v, file)
+	require.NoError(t, v.Link(file, file+"2"))
+	syncDir(t, v, tmpDir)
+
+	vos.Crash(v)
+
+	testos.AssertNotExists(t, v, testos.Join(dir, "sub"))
+	testos.AssertExists(t, v, dir)
+	testos.AssertFileData(t, v, file+"2", "data")
+	stat, err := v.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stat.Sys().(*vos.FileStat).Nlink)
+}
+
+func TestCrashClosesFiles(t *testing.T) {
+	v := vos.New()
+	f, err := v.Create(testos.Join(vos.MkTempDir(v), "file"))
+	require.NoError(t, err)
+
+	vos.Crash(v)
+
+	_, err = f.Write([]byte("data"))
+	assert.ErrorIs(t, err, fs.ErrClosed)
+	assert.ErrorIs(t, f.Close(), fs.ErrClosed)
+}
+
+func TestCrashPoints(t *testing.T) {
+	const cfg = "/home/config"
+	setup := func(o osa.I) {
+		testos.RequireWrite(t, o, cfg, "old")
+	}
+
+	replace := func(syncFile bool) func(o osa.I) {
+		return func(o osa.I) {
+			tmp := cfg + ".tmp"
+			f, err := o.Create(tmp)
+			require.NoError(t, err)
+			_, err = f.Write([]byte("new"))
+			require.NoError(t, err)
+			if syncFile {
+				require.NoError(t, f.Sync())
+			}
+			require.NoError(t, f.Close())
+			require.NoError(t, o.Rename(tmp, cfg))
+			syncDir(t, o, "/home")
+		}
+	}
+
+	t.Run("Synced", func(t *testing.T) {
+		var got []string
+		n := vos.CrashPoints(setup, replace(true), func(o osa.I, p vos.CrashPoint) {
+			data, err := o.ReadFile(cfg)
+			require.NoError(t, err, p)
+			assert.Contains(t, []string{"old", "new"}, string(data), p)
+			got = append(got, string(data))
+		})
+		assert.Equal(t, 9, n)
+		assert.Equal(t, "old", got[0])
+		assert.Equal(t, "new", got[n-1])
+	})
+
+	t.Run("Unsynced", func(t *testing.T) {
+		var torn []vos.CrashPoint
+		vos.CrashPoints(setup, replace(false), func(o osa.I, p vos.CrashPoint) {
+			if data, _ := o.ReadFile(cfg); string(data) != "old" && string(data) != "new" {
+				torn = append(torn, p)
+			}
+		})
+		assert.NotEmpty(t, torn)
+	})
+}
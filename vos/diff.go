@@ -0,0 +1,303 @@
+package vos
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	os "github.com/echocrow/osa"
+)
+
+// ChangeKind describes how an entry changed. Kinds may be combined, except
+// for Added and Removed.
+type ChangeKind uint
+
+// Kinds of changes.
+const (
+	Added          ChangeKind = 1 << iota // entry was added
+	Removed                               // entry was removed
+	TypeChanged                           // entry changed its type, e.g. from file to directory
+	ContentChanged                        // file data or symlink target changed
+	ModeChanged                           // permission bits changed
+	MtimeChanged                          // modification time of a file or symlink changed
+)
+
+var changeKindNames = []string{"added", "removed", "type", "content", "mode", "mtime"}
+
+func (k ChangeKind) String() string {
+	var names []string
+	for i, name := range changeKindNames {
+		if k&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// DiffEntry describes the state of a compared entry.
+type DiffEntry struct {
+	Mode    fs.FileMode
+	ModTime time.Time
+	Data    []byte // data of files
+	Target  string // target of symlinks
+}
+
+// Change describes a changed entry.
+type Change struct {
+	// Path is the slash-separated path of the entry, relative to the compared
+	// directories.
+	Path string
+	// Kind describes how the entry changed.
+	Kind ChangeKind
+	// Old is the state of the entry before the change. It is nil for added
+	// entries.
+	Old *DiffEntry
+	// New is the state of the entry after the change. It is nil for removed
+	// entries.
+	New *DiffEntry
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added, Removed:
+		return c.Kind.String() + " " + c.Path
+	}
+	return "modified " + c.Path + " (" + c.Kind.String() + ")"
+}
+
+// Changes lists changed entries, sorted by path.
+type Changes []Change
+
+// Paths returns the paths of all changes of any of the given kinds.
+func (cs Changes) Paths(kinds ChangeKind) []string {
+	var paths []string
+	for _, c := range cs {
+		if c.Kind&kinds != 0 {
+			paths = append(paths, c.Path)
+		}
+	}
+	return paths
+}
+
+// Ignore returns the changes without the given kinds. Changes of no other
+// kind are omitted.
+func (cs Changes) Ignore(kinds ChangeKind) Changes {
+	var l Changes
+	for _, c := range cs {
+		if c.Kind &^= kinds; c.Kind != 0 {
+			l = append(l, c)
+		}
+	}
+	return l
+}
+
+// String renders the changes similar to a unified diff of the git tool.
+func (cs Changes) String() string {
+	var b strings.Builder
+	for _, c := range cs {
+		writeChange(&b, c)
+	}
+	return b.String()
+}
+
+// Diff compares the directory dir between two snapshots.
+//
+// Directory modification times are not compared, as they change along with
+// their entries.
+func Diff(from, to State, dir string) (Changes, error) {
+	return DiffDirs(from.instance(), dir, to.instance(), dir)
+}
+
+// DiffDirs compares directory aDir of OS abstraction a with directory bDir of
+// OS abstraction b. Either may be a vos instance, or a different OS abstraction
+// implementation, e.g. oos.New() to compare with a real directory.
+//
+// Directory modification times are not compared, as they change along with
+// their entries.
+func DiffDirs(a os.I, aDir string, b os.I, bDir string) (Changes, error) {
+	aTree, err := readTree(a, aDir)
+	if err != nil {
+		return nil, err
+	}
+	bTree, err := readTree(b, bDir)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(aTree))
+	for p := range aTree {
+		paths = append(paths, p)
+	}
+	for p := range bTree {
+		if _, ok := aTree[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	var cs Changes
+	for _, p := range paths {
+		if c := diffEntry(p, aTree[p], bTree[p]); c.Kind != 0 {
+			cs = append(cs, c)
+		}
+	}
+	return cs, nil
+}
+
+// instance returns a new vos instance holding the snapshot.
+func (s State) instance() vos {
+	v := New()
+	Restore(v, s)
+	return v
+}
+
+func diffEntry(p string, old, new *DiffEntry) Change {
+	c := Change{Path: p, Old: old, New: new}
+	switch {
+	case old == nil:
+		c.Kind = Added
+	case new == nil:
+		c.Kind = Removed
+	case old.Mode.Type() != new.Mode.Type():
+		c.Kind = TypeChanged
+	default:
+		if string(old.Data) != string(new.Data) || old.Target != new.Target {
+			c.Kind |= ContentChanged
+		}
+		if old.Mode != new.Mode {
+			c.Kind |= ModeChanged
+		}
+		if !old.Mode.IsDir() && !old.ModTime.Equal(new.ModTime) {
+			c.Kind |= MtimeChanged
+		}
+	}
+	return c
+}
+
+// readTree reads all entries within a directory, keyed by their
+// slash-separated relative paths.
+func readTree(o os.I, dir string) (map[string]*DiffEntry, error) {
+	tree := make(map[string]*DiffEntry)
+	err := walkTree(o, dir, func(rel, name string, fi fs.FileInfo) (err error) {
+		d := &DiffEntry{Mode: fi.Mode(), ModTime: fi.ModTime()}
+		switch {
+		case fi.Mode()&fs.ModeSymlink != 0:
+			d.Target, err = o.Readlink(name)
+		case fi.Mode().IsRegular():
+			d.Data, err = o.ReadFile(name)
+		}
+		tree[rel] = d
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// walkTree calls fn for all entries within a directory, in lexical order,
+// with their slash-separated relative paths and their names. Directories are
+// visited before their entries. Symbolic links are not followed.
+func walkTree(o os.I, dir string, fn func(rel, name string, fi fs.FileInfo) error) error {
+	fi, err := o.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return &fs.PathError{Op: "walk", Path: dir, Err: errNotDir}
+	}
+	var walk func(dir, rel string) error
+	walk = func(dir, rel string) error {
+		entries, err := o.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			name := filepath.Join(dir, e.Name())
+			p := path.Join(rel, e.Name())
+			fi, err := o.Lstat(name)
+			if err != nil {
+				return err
+			}
+			if err := fn(p, name, fi); err != nil {
+				return err
+			}
+			if fi.IsDir() {
+				if err := walk(name, p); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return walk(dir, "")
+}
+
+// writeChange renders a change.
+func writeChange(b *strings.Builder, c Change) {
+	aName, bName := "a/"+c.Path, "b/"+c.Path
+	fmt.Fprintf(b, "diff %s %s\n", aName, bName)
+	switch {
+	case c.Kind == Added:
+		fmt.Fprintf(b, "new %s\n", describeEntry(c.New))
+		aName = "/dev/null"
+	case c.Kind == Removed:
+		fmt.Fprintf(b, "deleted %s\n", describeEntry(c.Old))
+		bName = "/dev/null"
+	case c.Kind&TypeChanged != 0:
+		fmt.Fprintf(b, "old %s\nnew %s\n", describeEntry(c.Old), describeEntry(c.New))
+	}
+	if c.Kind&ModeChanged != 0 {
+		fmt.Fprintf(b, "old mode %s\nnew mode %s\n", c.Old.Mode, c.New.Mode)
+	}
+	if c.Kind&MtimeChanged != 0 {
+		fmt.Fprintf(b, "old mtime %s\nnew mtime %s\n",
+			c.Old.ModTime.Format(time.RFC3339Nano),
+			c.New.ModTime.Format(time.RFC3339Nano),
+		)
+	}
+	if c.Kind&(Added|Removed|TypeChanged|ContentChanged) == 0 {
+		return
+	}
+	aText, aOK := diffText(c.Old)
+	bText, bOK := diffText(c.New)
+	if !aOK || !bOK {
+		fmt.Fprintf(b, "Binary files %s and %s differ\n", aName, bName)
+		return
+	}
+	if aText == bText {
+		return
+	}
+	fmt.Fprintf(b, "--- %s\n+++ %s\n", aName, bName)
+	writeUnified(b, splitLines(aText), splitLines(bText))
+}
+
+// describeEntry describes the type and mode of an entry.
+func describeEntry(d *DiffEntry) string {
+	switch {
+	case d.Mode.IsDir():
+		return "directory mode " + d.Mode.String()
+	case d.Mode&fs.ModeSymlink != 0:
+		return "symlink"
+	}
+	return "file mode " + d.Mode.String()
+}
+
+// diffText returns the text of an entry to render in a diff. It reports false
+// for binary data.
+func diffText(d *DiffEntry) (string, bool) {
+	switch {
+	case d == nil, d.Mode.IsDir():
+		return "", true
+	case d.Mode&fs.ModeSymlink != 0:
+		return d.Target + "\n", true
+	}
+	s := string(d.Data)
+	return s, !strings.ContainsRune(s, 0)
+}
@@ -0,0 +1,140 @@
+package vos_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/echocrow/osa/oos"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	clock := vos.NewFakeClock(time.Unix(0, 0))
+	v := vos.New(vos.WithClock(clock.Now))
+	dir := vos.MkTempDir(v)
+	testos.RequireWrite(t, v, testos.Join(dir, "same"), "same")
+	testos.RequireWrite(t, v, testos.Join(dir, "content"), "a\nb\nc\n")
+	testos.RequireWrite(t, v, testos.Join(dir, "mode"), "mode")
+	testos.RequireWrite(t, v, testos.Join(dir, "mtime"), "mtime")
+	testos.RequireWrite(t, v, testos.Join(dir, "removed"), "removed")
+	testos.RequireWrite(t, v, testos.Join(dir, "type"), "type")
+	require.NoError(t, v.Symlink("same", testos.Join(dir, "link")))
+	before := vos.Snapshot(v)
+
+	clock.Set(time.Unix(1, 0))
+	require.NoError(t, v.WriteFile(testos.Join(dir, "content"), []byte("a\nB\nc\n"), 0))
+	require.NoError(t, v.Chtimes(testos.Join(dir, "content"), time.Time{}, time.Unix(0, 0)))
+	require.NoError(t, v.Chmod(testos.Join(dir, "mode"), 0755))
+	require.NoError(t, v.Chtimes(testos.Join(dir, "mtime"), time.Time{}, time.Unix(2, 0)))
+	require.NoError(t, v.Remove(testos.Join(dir, "removed")))
+	require.NoError(t, v.Remove(testos.Join(dir, "type")))
+	testos.RequireMkdir(t, v, testos.Join(dir, "type"))
+	require.NoError(t, v.Remove(testos.Join(dir, "link")))
+	require.NoError(t, v.Symlink("mode", testos.Join(dir, "link")))
+	testos.RequireMkdirAll(t, v, testos.Join(dir, "new", "sub"))
+	testos.RequireWrite(t, v, testos.Join(dir, "new", "sub", "file"), "new")
+
+	changes, err := vos.Diff(before, vos.Snapshot(v), dir)
+	require.NoError(t, err)
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+	assert.Equal(t, []string{
+		"modified content (content)",
+		"modified link (content, mtime)",
+		"modified mode (mode)",
+		"modified mtime (mtime)",
+		"added new",
+		"added new/sub",
+		"added new/sub/file",
+		"removed removed",
+		"modified type (type)",
+	}, got)
+
+	assert.Equal(t, []string{"new", "new/sub", "new/sub/file"}, changes.Paths(vos.Added))
+	assert.Equal(t, []string{"content", "link"}, changes.Paths(vos.ContentChanged))
+	assert.Len(t, changes.Ignore(vos.MtimeChanged|vos.ModeChanged), 7)
+
+	removed := changes[7]
+	assert.Equal(t, vos.Removed, removed.Kind)
+	assert.Equal(t, "removed", string(removed.Old.Data))
+	assert.Nil(t, removed.New)
+}
+
+func TestDiffString(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	testos.RequireWrite(t, v, testos.Join(dir, "file"), "1\n2\n3\n4\n5\n6\n7\n8\n9\n")
+	testos.RequireWrite(t, v, testos.Join(dir, "old"), "old")
+	testos.RequireWrite(t, v, testos.Join(dir, "bin"), "\x00")
+	before := vos.Snapshot(v)
+
+	testos.RequireWrite(t, v, testos.Join(dir, "file"), "1\n2\n3\n4\nfive\n6\n7\n8\n9\nten\n")
+	require.NoError(t, v.Remove(testos.Join(dir, "old")))
+	testos.RequireWrite(t, v, testos.Join(dir, "bin"), "\x01\x00")
+	require.NoError(t, v.Chmod(testos.Join(dir, "bin"), 0644))
+
+	changes, err := vos.Diff(before, vos.Snapshot(v), dir)
+	require.NoError(t, err)
+	changes = changes.Ignore(vos.MtimeChanged)
+	assert.Equal(t, `diff a/bin b/bin
+old mode -rw-------
+new mode -rw-r--r--
+Binary files a/bin and b/bin differ
+diff a/file b/file
+--- a/file
++++ b/file
+@@ -2,8 +2,9 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+ 9
++ten
+diff a/old b/old
+deleted file mode -rw-------
+--- a/old
++++ /dev/null
+@@ -1 +0,0 @@
+-old
+\ No newline at end of file
+`, changes.String())
+}
+
+func TestDiffDirs(t *testing.T) {
+	real := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(real, "sub"), 0755))
+	require.NoError(t, os.Chmod(filepath.Join(real, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(real, "sub", "file"), []byte("data"), 0644))
+	require.NoError(t, os.Chmod(filepath.Join(real, "sub", "file"), 0644))
+	require.NoError(t, os.Symlink("sub/file", filepath.Join(real, "link")))
+
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	require.NoError(t, v.MkdirAll(testos.Join(dir, "sub"), 0755))
+	require.NoError(t, v.WriteFile(testos.Join(dir, "sub", "file"), []byte("data"), 0644))
+	require.NoError(t, v.Symlink("sub/file", testos.Join(dir, "link")))
+
+	changes, err := vos.DiffDirs(v, dir, oos.New(), real)
+	require.NoError(t, err)
+	assert.Empty(t, changes.Ignore(vos.MtimeChanged))
+
+	require.NoError(t, v.WriteFile(testos.Join(dir, "extra"), nil, 0644))
+	changes, err = vos.DiffDirs(v, dir, oos.New(), real)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"extra"}, changes.Ignore(vos.MtimeChanged).Paths(vos.Removed))
+
+	_, err = vos.DiffDirs(v, testos.Join(dir, "missing"), oos.New(), real)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
@@ -0,0 +1,93 @@
+package vos
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// WithEnv seeds the environment of the vos instance with the given variables.
+func WithEnv(env map[string]string) Option {
+	return func(c *config) {
+		for key, val := range env {
+			c.env = append(c.env, key+"="+val)
+		}
+	}
+}
+
+// WithOsEnv seeds the environment of the vos instance with a copy of the
+// environment of the current process.
+func WithOsEnv() Option {
+	return func(c *config) {
+		c.env = append(c.env, os.Environ()...)
+	}
+}
+
+type vosEnv struct {
+	mu  *sync.RWMutex
+	env map[string]string
+}
+
+func newEnv(c config) vosEnv {
+	v := vosEnv{
+		mu:  new(sync.RWMutex),
+		env: make(map[string]string, len(c.env)),
+	}
+	v.setEnviron(c.env)
+	return v
+}
+
+func (v vosEnv) Getenv(key string) string {
+	val, _ := v.LookupEnv(key)
+	return val
+}
+
+func (v vosEnv) LookupEnv(key string) (string, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	val, ok := v.env[key]
+	return val, ok
+}
+
+func (v vosEnv) Setenv(key, value string) error {
+	if key == "" || strings.ContainsAny(key, "=\x00") {
+		return os.NewSyscallError("setenv", syscall.EINVAL)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.env[key] = value
+	return nil
+}
+
+func (v vosEnv) Unsetenv(key string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.env, key)
+	return nil
+}
+
+func (v vosEnv) Environ() []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	env := make([]string, 0, len(v.env))
+	for key, val := range v.env {
+		env = append(env, key+"="+val)
+	}
+	sort.Strings(env)
+	return env
+}
+
+func (v vosEnv) ExpandEnv(s string) string {
+	return os.Expand(s, v.Getenv)
+}
+
+// setEnviron adds env entries in the form "key=value".
+func (v vosEnv) setEnviron(env []string) {
+	for _, kv := range env {
+		if i := strings.Index(kv, "="); i > 0 {
+			v.env[kv[:i]] = kv[i+1:]
+		}
+	}
+}
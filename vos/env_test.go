@@ -0,0 +1,54 @@
+package vos_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvIsolated(t *testing.T) {
+	v1 := vos.New()
+	v2 := vos.New()
+
+	assert.Empty(t, v1.Environ())
+
+	assert.NoError(t, v1.Setenv("FOO", "bar"))
+	assert.Equal(t, "bar", v1.Getenv("FOO"))
+	_, ok := v2.LookupEnv("FOO")
+	assert.False(t, ok, "expected env of other instance to be unaffected")
+	_, ok = os.LookupEnv("FOO")
+	assert.False(t, ok, "expected process env to be unaffected")
+}
+
+func TestWithEnv(t *testing.T) {
+	env := map[string]string{
+		"FOO": "bar",
+		"BAZ": "",
+	}
+	v := vos.New(vos.WithEnv(env))
+
+	assert.Equal(t, []string{"BAZ=", "FOO=bar"}, v.Environ())
+
+	assert.NoError(t, v.Setenv("FOO", "qux"))
+	assert.Equal(t, "bar", env["FOO"], "expected seed map to be unaffected")
+}
+
+func TestWithOsEnv(t *testing.T) {
+	t.Setenv("OSA_TEST_OS_ENV", "some value")
+	t.Setenv("OSA_TEST_OS_UNSET", "")
+	require.NoError(t, os.Unsetenv("OSA_TEST_OS_UNSET"))
+
+	v := vos.New(vos.WithOsEnv())
+	assert.Equal(t, "some value", v.Getenv("OSA_TEST_OS_ENV"))
+	assert.Contains(t, v.Environ(), "OSA_TEST_OS_ENV=some value")
+	_, ok := v.LookupEnv("OSA_TEST_OS_UNSET")
+	assert.False(t, ok)
+
+	assert.NoError(t, v.Setenv("OSA_TEST_OS_ENV", "other value"))
+	assert.Equal(t, "some value", os.Getenv("OSA_TEST_OS_ENV"))
+	assert.NoError(t, v.Unsetenv("OSA_TEST_OS_ENV"))
+	assert.Equal(t, "some value", os.Getenv("OSA_TEST_OS_ENV"))
+}
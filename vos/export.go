@@ -0,0 +1,188 @@
+package vos
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ExportDir copies all files, directories, and symbolic links within the
+// directory dir of the vos instance into the real directory dst, e.g. to
+// inspect the files a failed test left behind:
+//
+//	t.Cleanup(func() {
+//		if t.Failed() {
+//			dst := filepath.Join("testdata", "failed", t.Name())
+//			if err := vos.ExportDir(v, dir, dst); err != nil {
+//				t.Log(err)
+//			}
+//		}
+//	})
+//
+// Missing parent directories of dst are created as needed. Existing files are
+// overwritten. Modes and modification times are preserved, except for the
+// modification times of symbolic links.
+//
+// ExportDir always writes to the real file system, regardless of patches of
+// the OS abstraction.
+func ExportDir(v vos, dir, dst string) error {
+	if err := os.MkdirAll(dst, 0777); err != nil {
+		return err
+	}
+	var dirs []string
+	var infos []fs.FileInfo
+	err := walkTree(v, dir, func(rel, name string, fi fs.FileInfo) error {
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+		switch {
+		case fi.Mode()&fs.ModeSymlink != 0:
+			link, err := v.Readlink(name)
+			if err != nil {
+				return err
+			}
+			if err := removeExported(target); err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case fi.IsDir():
+			// Keep the directory writable until its entries are copied, also if
+			// a previous export made it read-only.
+			if err := os.MkdirAll(target, 0700); err != nil {
+				return err
+			}
+			if err := os.Chmod(target, 0700); err != nil {
+				return err
+			}
+			dirs, infos = append(dirs, target), append(infos, fi)
+			return nil
+		case fi.Mode().IsRegular():
+			data, err := v.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			// Replace rather than overwrite files, which may be read-only.
+			if err := removeExported(target); err != nil {
+				return err
+			}
+			if err := os.WriteFile(target, data, 0600); err != nil {
+				return err
+			}
+			return exportMeta(target, fi)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Apply directory metadata last and in post-order, as copying entries
+	// alters it, and read-only directories prevent copying.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := exportMeta(dirs[i], infos[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeExported removes a previously exported file or symbolic link, if any.
+func removeExported(name string) error {
+	fi, err := os.Lstat(name)
+	if os.IsNotExist(err) || err == nil && fi.IsDir() {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+// exportMeta applies the mode and modification time of fi to the named real
+// file.
+func exportMeta(name string, fi fs.FileInfo) error {
+	if err := os.Chmod(name, fi.Mode()&modeMask); err != nil {
+		return err
+	}
+	return os.Chtimes(name, fi.ModTime(), fi.ModTime())
+}
+
+// ExportTar writes all files, directories, and symbolic links within the
+// directory dir of the vos instance to w as a tar archive, preserving their
+// modes and modification times. Paths in the archive are relative to dir.
+func ExportTar(v vos, dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := walkTree(v, dir, func(rel, name string, fi fs.FileInfo) error {
+		var link string
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			var err error
+			if link, err = v.Readlink(name); err != nil {
+				return err
+			}
+		}
+		h, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		h.Name = rel
+		if fi.IsDir() {
+			h.Name += "/"
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		data, err := v.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// ExportZip writes all files, directories, and symbolic links within the
+// directory dir of the vos instance to w as a zip archive, preserving their
+// modes and modification times. Paths in the archive are relative to dir.
+func ExportZip(v vos, dir string, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	err := walkTree(v, dir, func(rel, name string, fi fs.FileInfo) error {
+		h, err := zip.FileInfoHeader(fi)
+		if err != nil {
+			return err
+		}
+		h.Name = rel
+		var data []byte
+		switch {
+		case fi.IsDir():
+			h.Name += "/"
+		case fi.Mode()&fs.ModeSymlink != 0:
+			// Symbolic links store their target as data.
+			link, err := v.Readlink(name)
+			if err != nil {
+				return err
+			}
+			data = []byte(link)
+		case fi.Mode().IsRegular():
+			if data, err = v.ReadFile(name); err != nil {
+				return err
+			}
+		}
+		fw, err := zw.CreateHeader(h)
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,132 @@
+package vos_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/oos"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportFixture writes files, a read-only directory, and a symlink into dir.
+func exportFixture(t *testing.T, o osa.I, clock *vos.FakeClock, dir string) {
+	t.Helper()
+	testos.RequireMkdirAll(t, o, testos.Join(dir, "ro"))
+	testos.RequireWrite(t, o, testos.Join(dir, "ro", "file"), "ro")
+	require.NoError(t, o.Chmod(testos.Join(dir, "ro", "file"), 0444))
+	require.NoError(t, o.Chmod(testos.Join(dir, "ro"), 0555))
+	testos.RequireWrite(t, o, testos.Join(dir, "exec"), "#!/bin/sh\n")
+	require.NoError(t, o.Chmod(testos.Join(dir, "exec"), 0750))
+	clock.Set(time.Unix(2000, 0))
+	testos.RequireWrite(t, o, testos.Join(dir, "new"), "new")
+	require.NoError(t, o.Symlink("ro/file", testos.Join(dir, "link")))
+}
+
+func TestExportDir(t *testing.T) {
+	clock := vos.NewFakeClock(time.Unix(1000, 0))
+	v := vos.New(vos.WithClock(clock.Now))
+	dir := vos.MkTempDir(v)
+	exportFixture(t, v, clock, dir)
+
+	dst := filepath.Join(t.TempDir(), "out")
+	t.Cleanup(func() {
+		// Allow the test cleanup to remove the read-only directory.
+		_ = os.Chmod(filepath.Join(dst, "ro"), 0755)
+	})
+	require.NoError(t, vos.ExportDir(v, dir, dst))
+	changes, err := vos.DiffDirs(v, dir, oos.New(), dst)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"link"}, changes.Paths(vos.MtimeChanged))
+	assert.Empty(t, changes.Ignore(vos.MtimeChanged))
+
+	// Exporting again overwrites existing files, including read-only ones.
+	testos.RequireWrite(t, v, testos.Join(dir, "new"), "newer")
+	require.NoError(t, v.Chmod(testos.Join(dir, "ro"), 0755))
+	require.NoError(t, v.Chmod(testos.Join(dir, "ro", "file"), 0644))
+	testos.RequireWrite(t, v, testos.Join(dir, "ro", "file"), "still ro")
+	require.NoError(t, v.Chmod(testos.Join(dir, "ro", "file"), 0444))
+	require.NoError(t, v.Chmod(testos.Join(dir, "ro"), 0555))
+	require.NoError(t, vos.ExportDir(v, dir, dst))
+	data, err := os.ReadFile(filepath.Join(dst, "new"))
+	require.NoError(t, err)
+	assert.Equal(t, "newer", string(data))
+	changes, err = vos.DiffDirs(v, dir, oos.New(), dst)
+	require.NoError(t, err)
+	assert.Empty(t, changes.Ignore(vos.MtimeChanged))
+}
+
+func TestExportTar(t *testing.T) {
+	clock := vos.NewFakeClock(time.Unix(1000, 0))
+	v := vos.New(vos.WithClock(clock.Now))
+	dir := vos.MkTempDir(v)
+	exportFixture(t, v, clock, dir)
+
+	var buf bytes.Buffer
+	require.NoError(t, vos.ExportTar(v, dir, &buf))
+	type entry struct {
+		name  string
+		mode  fs.FileMode
+		mtime int64
+		link  string
+		data  string
+	}
+	var got []entry
+	tr := tar.NewReader(&buf)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		got = append(got, entry{h.Name, h.FileInfo().Mode(), h.ModTime.Unix(), h.Linkname, string(data)})
+	}
+	assert.Equal(t, []entry{
+		{"exec", 0750, 1000, "", "#!/bin/sh\n"},
+		{"link", fs.ModeSymlink | 0777, 2000, "ro/file", ""},
+		{"new", 0600, 2000, "", "new"},
+		{"ro/", fs.ModeDir | 0555, 1000, "", ""},
+		{"ro/file", 0444, 1000, "", "ro"},
+	}, got)
+}
+
+func TestExportZip(t *testing.T) {
+	clock := vos.NewFakeClock(time.Unix(1000, 0))
+	v := vos.New(vos.WithClock(clock.Now))
+	dir := vos.MkTempDir(v)
+	exportFixture(t, v, clock, dir)
+
+	var buf bytes.Buffer
+	require.NoError(t, vos.ExportZip(v, dir, &buf))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"exec", "link", "new", "ro/", "ro/file"}, names)
+	assert.Equal(t, fs.ModeSymlink, zr.File[1].Mode().Type())
+	data, err := fs.ReadFile(zr, "link")
+	require.NoError(t, err)
+	assert.Equal(t, "ro/file", string(data))
+
+	// Loading the archive restores all but the symlink.
+	w := vos.New()
+	require.NoError(t, vos.Load(w, "/out", zr))
+	changes, err := vos.DiffDirs(v, dir, w, "/out")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"link"}, changes.Paths(vos.Removed))
+	assert.Len(t, changes, 1)
+}
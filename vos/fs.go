@@ -4,17 +4,20 @@ import (
 	"fmt"
 	"io/fs"
 	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
 
 	os "github.com/echocrow/osa"
 )
 
 type vosFS struct {
-	vfs
+	*vfs
 }
 
-func newFS() vosFS {
+func newFS(c config) vosFS {
 	return vosFS{
-		vfs: newVFS(),
+		vfs: newVFS(c),
 	}
 }
 
@@ -22,16 +25,16 @@ func (vosFS) PathSeparator() uint8 {
 	return filepath.Separator
 }
 
-func (v vosFS) Stat(name string) (os.FileInfo, error) {
-	return fs.Stat(v.vfs, name)
-}
-
 func (vosFS) IsExist(err error) bool {
-	return underlyingError(err) == fs.ErrExist
+	return underlyingErrorIs(err, fs.ErrExist)
 }
 
 func (vosFS) IsNotExist(err error) bool {
-	return underlyingError(err) == fs.ErrNotExist
+	return underlyingErrorIs(err, fs.ErrNotExist)
+}
+
+func (vosFS) IsPermission(err error) bool {
+	return underlyingErrorIs(err, fs.ErrPermission)
 }
 
 func (v vosFS) IsPathSeparator(c uint8) bool {
@@ -39,26 +42,35 @@ func (v vosFS) IsPathSeparator(c uint8) bool {
 }
 
 func (v vosFS) MkdirAll(name string, perm fs.FileMode) error {
-	dir := v.entries
-	for _, n := range v.splitPath(name) {
-		got := dir.tryGet(n)
-		if got == nil {
-			d := newVDir()
-			if err := dir.add(n, d); err != nil {
-				return newPathError("mkdir", name, err)
-			}
-			dir = d
-		} else {
-			var ok bool
-			if dir, ok = got.(vDir); !ok {
-				return newPathError("mkdir", name, errNotDir)
-			}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.mkdirAll(name, perm)
+}
+
+func (v vosFS) mkdirAll(name string, perm fs.FileMode) error {
+	if e, err := v.get(name); err == nil {
+		if e.isDir() {
+			return nil
+		}
+		return newPathError("mkdir", name, errNotDir)
+	}
+	if parent := filepath.Dir(name); parent != name {
+		if err := v.mkdirAll(parent, perm); err != nil {
+			return err
 		}
 	}
+	if err := v.mkdir(name, perm); err != nil {
+		if e, err1 := v.lget(name); err1 == nil && e.isDir() {
+			return nil
+		}
+		return err
+	}
 	return nil
 }
 
 func (v vosFS) MkdirTemp(dir, pattern string) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 	if dir == "" {
 		dir = v.temp
 	}
@@ -69,7 +81,7 @@ func (v vosFS) MkdirTemp(dir, pattern string) (string, error) {
 		if tmpDirSfx == 0 {
 			return "", newPathError("mkdir", path, errOpFailed)
 		}
-		if err := v.Mkdir(path, 0700); !v.IsExist(err) {
+		if err := v.mkdir(path, 0700); !v.IsExist(err) {
 			return path, err
 		}
 		tmpDirSfx++
@@ -77,81 +89,331 @@ func (v vosFS) MkdirTemp(dir, pattern string) (string, error) {
 }
 
 func (v vosFS) ReadDir(name string) ([]fs.DirEntry, error) {
-	return fs.ReadDir(v.vfs, name)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	f, err := v.openFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	dir, ok := f.entry.(*vDir)
+	if !ok {
+		return nil, newPathError("readdirent", name, errNotDir)
+	}
+	contents := dir.list(v.dev)
+	sort.Slice(contents, func(i, j int) bool {
+		return contents[i].name < contents[j].name
+	})
+	entries := make([]fs.DirEntry, len(contents))
+	for i, c := range contents {
+		entries[i] = c
+	}
+	return entries, nil
 }
 
-func (v vosFS) WriteFile(name string, data []byte, perm os.FileMode) error {
-	parent, base := filepath.Split(name)
-	parDir, err := v.getDir(parent)
+func (v vosFS) OpenFile(name string, flag int, perm os.FileMode) (os.File, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	f, err := v.openFile(name, flag, perm)
 	if err != nil {
-		return newPathError("open", parent, err)
-	}
-	if err := parDir.update(base, newVFile(data)); err != nil {
-		return newPathError("write", name, err)
+		return nil, err
 	}
-	return nil
+	return f, nil
 }
 
-func (v vosFS) ReadFile(name string) ([]byte, error) {
-	return fs.ReadFile(v.vfs, name)
+func (v vosFS) Create(name string) (os.File, error) {
+	return v.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
 }
 
-func (v vosFS) Rename(oldpath, newpath string) error {
-	oldE, err := v.get(oldpath)
+func (v vosFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	f, err := v.openFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
 	if err != nil {
-		return newPathError("rename", oldpath, err)
+		return err
 	}
+	_, err = f.write(data)
+	f.isClosed = true
+	return err
+}
 
-	oParent, oBase := filepath.Split(oldpath)
-	oParDir, err := v.getDir(oParent)
+func (v vosFS) ReadFile(name string) ([]byte, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	f, err := v.openFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	file, err := f.file("read", false)
+	if err != nil {
+		return nil, err
+	}
+	file.atime = v.now()
+	data := make([]byte, len(file.data))
+	copy(data, file.data)
+	return data, nil
+}
+
+func (v vosFS) Rename(oldpath, newpath string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	oParDir, oBase, err := v.resolve(oldpath)
 	if err != nil {
 		return newPathError("rename", oldpath, err)
 	}
-	if !oParDir.has(oBase) {
+	oldE := oParDir.tryGet(oBase)
+	if oBase == "" || oldE == nil {
 		return newPathError("rename", oldpath, fs.ErrNotExist)
 	}
+	if v.abs(oldpath) == v.abs(newpath) {
+		return nil
+	}
 
-	nParent, nBase := filepath.Split(newpath)
-	nParDir, err := v.getDir(nParent)
+	nLoc, err := v.resolveLocation(newpath)
 	if err != nil {
 		return newPathError("rename", newpath, err)
 	}
-	if collE, err := nParDir.get(nBase); err == nil && collE.isDir() {
+	// Reject moving a directory into its own subtree, also via symbolic links.
+	for _, d := range nLoc.dirs {
+		if d == v.latest(oldE) {
+			return newPathError("rename", newpath, fs.ErrInvalid)
+		}
+	}
+	nParDir, nBase := nLoc.parent, nLoc.base
+	if nBase == "" {
 		return newPathError("rename", newpath, fs.ErrExist)
 	}
+	collE := nParDir.tryGet(nBase)
+	if collE == oldE {
+		return nil
+	}
+	if collE != nil && collE.isDir() {
+		return newPathError("rename", newpath, fs.ErrExist)
+	}
+	if err := v.access(oParDir, permW); err != nil {
+		return newPathError("rename", oldpath, err)
+	}
+	if err := v.access(nParDir, permW); err != nil {
+		return newPathError("rename", newpath, err)
+	}
+	if err := v.reserveMove(oldE, oParDir, nParDir); err != nil {
+		return newPathError("rename", newpath, err)
+	}
 	if err := nParDir.update(nBase, oldE); err != nil {
 		return newPathError("rename", newpath, err)
 	}
+	if collE != nil {
+		collE.meta().nlink--
+	}
 	oParDir.delete(oBase)
+	v.dropUsage()
+	now := v.now()
+	oParDir.modified(now)
+	nParDir.modified(now)
+	oldE.meta().changed(now)
 	return nil
 }
 
 func (v vosFS) Remove(name string) error {
-	parent, base := filepath.Split(name)
-	parDir, err := v.getDir(parent)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	parDir, base, err := v.resolve(name)
 	if err != nil {
-		return err
+		return newPathError("remove", name, err)
+	}
+	if base == "" {
+		return newPathError("remove", name, errNotEmpty)
 	}
 	e, err := parDir.get(base)
 	if err != nil {
-		return newPathError("open", parent, err)
+		return newPathError("remove", name, err)
+	}
+	if err := v.access(parDir, permW); err != nil {
+		return newPathError("remove", name, err)
 	}
 	if e.isDir() && !e.isEmpty() {
 		return newPathError("remove", name, errNotEmpty)
 	}
-	parDir.delete(base)
+	v.unlink(parDir, base)
 	return nil
 }
 
 func (v vosFS) RemoveAll(name string) error {
-	parent, base := filepath.Split(name)
-	if parDir, err := v.getDir(parent); err == nil {
-		parDir.delete(base)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if name == "" {
+		return nil
+	}
+	if base := filepath.Base(name); base == "." || base == ".." {
+		return newPathError("RemoveAll", name, fs.ErrInvalid)
+	}
+	parDir, base, err := v.resolve(name)
+	if err != nil || base == "" {
+		return nil
 	}
+	e := parDir.tryGet(base)
+	if e == nil {
+		return nil
+	}
+	if err := v.access(parDir, permW); err != nil {
+		return newPathError("unlinkat", name, err)
+	}
+	if err := v.canRemoveAll(e); err != nil {
+		return newPathError("unlinkat", name, err)
+	}
+	v.unlinkAll(parDir, base)
 	return nil
 }
 
+// canRemoveAll checks whether an entry and all its children may be removed.
+func (v vosFS) canRemoveAll(e dirEntry) error {
+	dir, ok := e.(*vDir)
+	if !ok || dir.isEmpty() {
+		return nil
+	}
+	if err := v.access(dir, permR|permW|permX); err != nil {
+		return err
+	}
+	for _, c := range dir.dirEntries {
+		if err := v.canRemoveAll(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v vosFS) Chmod(name string, mode os.FileMode) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	e, err := v.get(name)
+	if err != nil {
+		return newPathError("chmod", name, err)
+	}
+	if !v.isOwner(e) {
+		return newPathError("chmod", name, fs.ErrPermission)
+	}
+	e.meta().perm = mode & modeMask
+	e.meta().changed(v.now())
+	return nil
+}
+
+func (v vosFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	e, err := v.get(name)
+	if err != nil {
+		return newPathError("chtimes", name, err)
+	}
+	if !v.isOwner(e) {
+		return newPathError("chtimes", name, fs.ErrPermission)
+	}
+	m := e.meta()
+	if !atime.IsZero() {
+		m.atime = atime
+	}
+	if !mtime.IsZero() {
+		m.mtime = mtime
+	}
+	m.changed(v.now())
+	return nil
+}
+
+func (v vosFS) Lstat(name string) (os.FileInfo, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	e, err := v.lget(name)
+	if err != nil {
+		return nil, newPathError("lstat", name, err)
+	}
+	return newFileInfo(filepath.Base(v.abs(name)), e, v.dev), nil
+}
+
+func (v vosFS) Symlink(oldname, newname string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	parDir, base, err := v.resolve(newname)
+	if err != nil {
+		return newLinkError("symlink", oldname, newname, err)
+	}
+	if base == "" || parDir.has(base) {
+		return newLinkError("symlink", oldname, newname, fs.ErrExist)
+	}
+	if err := v.access(parDir, permW); err != nil {
+		return newLinkError("symlink", oldname, newname, err)
+	}
+	if err := v.reserveEntry(parDir); err != nil {
+		return newLinkError("symlink", oldname, newname, err)
+	}
+	meta := v.newMeta(0)
+	meta.perm = fs.ModePerm
+	link := newVSymlink(meta, oldname)
+	if err := parDir.add(base, link); err != nil {
+		return newLinkError("symlink", oldname, newname, err)
+	}
+	v.addUsage(parDir, link)
+	parDir.modified(v.now())
+	return nil
+}
+
+func (v vosFS) Link(oldname, newname string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	e, err := v.lget(oldname)
+	if err != nil {
+		return newLinkError("link", oldname, newname, err)
+	}
+	if e.isDir() {
+		return newLinkError("link", oldname, newname, fs.ErrPermission)
+	}
+	parDir, base, err := v.resolve(newname)
+	if err != nil {
+		return newLinkError("link", oldname, newname, err)
+	}
+	if base == "" || parDir.has(base) {
+		return newLinkError("link", oldname, newname, fs.ErrExist)
+	}
+	if err := v.access(parDir, permW); err != nil {
+		return newLinkError("link", oldname, newname, err)
+	}
+	if err := v.reserveLink(e, parDir); err != nil {
+		return newLinkError("link", oldname, newname, err)
+	}
+	if err := parDir.add(base, e); err != nil {
+		return newLinkError("link", oldname, newname, err)
+	}
+	v.addUsage(parDir, e)
+	now := v.now()
+	parDir.modified(now)
+	e.meta().nlink++
+	e.meta().changed(now)
+	return nil
+}
+
+func (vosFS) SameFile(fi1, fi2 os.FileInfo) bool {
+	s1, ok1 := fi1.Sys().(*FileStat)
+	s2, ok2 := fi2.Sys().(*FileStat)
+	if !ok1 || !ok2 {
+		return false
+	}
+	return s1.Dev == s2.Dev && s1.Ino == s2.Ino
+}
+
+func (v vosFS) Readlink(name string) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	e, err := v.lget(name)
+	if err != nil {
+		return "", newPathError("readlink", name, err)
+	}
+	l, ok := e.(*vSymlink)
+	if !ok {
+		return "", newPathError("readlink", name, fs.ErrInvalid)
+	}
+	return l.target, nil
+}
+
 func (v vosFS) Getwd() (dir string, err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 	return v.pwd, nil
 }
 
@@ -176,15 +438,37 @@ func MkTempDir(v vos) string {
 	return path
 }
 
+// underlyingErrorIs reports whether the underlying error of err is target,
+// matching system errors like os.IsExist and similar do.
+func underlyingErrorIs(err, target error) bool {
+	err = underlyingError(err)
+	if err == target {
+		return true
+	}
+	errno, ok := err.(syscall.Errno)
+	return ok && errno.Is(target)
+}
+
 // underlyingError returns the underlying error for known os error types.
 func underlyingError(err error) error {
 	switch err := err.(type) {
 	case *os.PathError:
 		return err.Err
+	case *os.LinkError:
+		return err.Err
 	}
 	return err
 }
 
+func newLinkError(op, oldname, newname string, err error) *os.LinkError {
+	return &os.LinkError{
+		Op:  op,
+		Old: oldname,
+		New: newname,
+		Err: err,
+	}
+}
+
 func newPathError(op, path string, err error) *os.PathError {
 	return &os.PathError{
 		Op:   op,
@@ -0,0 +1,234 @@
+package vos_test
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUmask(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []vos.Option
+		wantDir  fs.FileMode
+		wantFile fs.FileMode
+	}{
+		{"default", nil, 0755, 0644},
+		{"custom", []vos.Option{vos.WithUmask(0027)}, 0750, 0640},
+		{"none", []vos.Option{vos.WithUmask(0)}, 0777, 0666},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := vos.New(tc.opts...)
+			tmpDir := vos.MkTempDir(v)
+
+			dir := testos.Join(tmpDir, "dir")
+			require.NoError(t, v.MkdirAll(testos.Join(dir, "sub"), 0777))
+			file := testos.Join(tmpDir, "file")
+			require.NoError(t, v.WriteFile(file, nil, 0666))
+
+			for _, p := range []string{dir, testos.Join(dir, "sub")} {
+				stat, err := v.Stat(p)
+				require.NoError(t, err)
+				assert.Equal(t, fs.ModeDir|tc.wantDir, stat.Mode())
+			}
+			stat, err := v.Stat(file)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantFile, stat.Mode())
+		})
+	}
+}
+
+func TestPermissionsNotEnforced(t *testing.T) {
+	v := vos.New()
+	tmpDir := vos.MkTempDir(v)
+	file := testos.Join(tmpDir, "file")
+	require.NoError(t, v.WriteFile(file, []byte("some data"), 0))
+
+	testos.AssertFileData(t, v, file, "some data")
+	assert.NoError(t, v.WriteFile(file, []byte("other data"), 0))
+}
+
+func TestPermissionsEnforced(t *testing.T) {
+	v := vos.New(vos.WithUser(1000, 1000))
+	tmpDir := vos.MkTempDir(v)
+	join := func(elem ...string) string {
+		return testos.Join(append([]string{tmpDir}, elem...)...)
+	}
+
+	assertDenied := func(t *testing.T, err error) {
+		t.Helper()
+		assert.ErrorIs(t, err, fs.ErrPermission)
+		assert.True(t, v.IsPermission(err), "want permission error")
+	}
+
+	t.Run("ReadOnlyFile", func(t *testing.T) {
+		file := join("read-only")
+		require.NoError(t, v.WriteFile(file, []byte("some data"), 0400))
+
+		testos.AssertFileData(t, v, file, "some data")
+		assertDenied(t, v.WriteFile(file, nil, 0600))
+		_, err := v.OpenFile(file, osa.O_RDWR, 0)
+		assertDenied(t, err)
+		_, err = v.OpenFile(file, osa.O_RDONLY|osa.O_TRUNC, 0)
+		assertDenied(t, err)
+		testos.AssertFileData(t, v, file, "some data")
+	})
+	t.Run("WriteOnlyFile", func(t *testing.T) {
+		file := join("write-only")
+		require.NoError(t, v.WriteFile(file, nil, 0200))
+
+		_, err := v.ReadFile(file)
+		assertDenied(t, err)
+		assert.NoError(t, v.WriteFile(file, []byte("some data"), 0600))
+	})
+	t.Run("ReadOnlyDir", func(t *testing.T) {
+		dir := join("read-only-dir")
+		testos.RequireMkdir(t, v, dir)
+		testos.RequireEmptyWrite(t, v, testos.Join(dir, "file"))
+		testos.RequireMkdir(t, v, testos.Join(dir, "sub"))
+		require.NoError(t, v.Chmod(dir, 0500))
+
+		_, err := v.ReadDir(dir)
+		assert.NoError(t, err)
+		assertDenied(t, v.WriteFile(testos.Join(dir, "new"), nil, 0600))
+		assertDenied(t, v.Mkdir(testos.Join(dir, "new"), 0700))
+		assertDenied(t, v.MkdirAll(testos.Join(dir, "new", "deep"), 0700))
+		assertDenied(t, v.Remove(testos.Join(dir, "file")))
+		assertDenied(t, v.RemoveAll(testos.Join(dir, "sub")))
+		assertDenied(t, v.Rename(testos.Join(dir, "file"), join("moved")))
+		assertDenied(t, v.Rename(join("write-only"), testos.Join(dir, "moved")))
+		assert.NoError(t, v.WriteFile(testos.Join(dir, "file"), []byte("x"), 0))
+		testos.AssertExists(t, v, testos.Join(dir, "file"))
+	})
+	t.Run("UnsearchableDir", func(t *testing.T) {
+		dir := join("unsearchable")
+		testos.RequireMkdir(t, v, dir)
+		testos.RequireEmptyWrite(t, v, testos.Join(dir, "file"))
+		require.NoError(t, v.Chmod(dir, 0600))
+
+		entries, err := v.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		_, err = v.Stat(testos.Join(dir, "file"))
+		assertDenied(t, err)
+		_, err = v.ReadFile(testos.Join(dir, "file"))
+		assertDenied(t, err)
+		assertDenied(t, v.Chdir(dir))
+	})
+	t.Run("UnreadableDir", func(t *testing.T) {
+		dir := join("unreadable")
+		testos.RequireMkdir(t, v, dir)
+		testos.RequireEmptyWrite(t, v, testos.Join(dir, "file"))
+		require.NoError(t, v.Chmod(dir, 0300))
+
+		_, err := v.ReadDir(dir)
+		assertDenied(t, err)
+		_, err = v.Stat(testos.Join(dir, "file"))
+		assert.NoError(t, err)
+		assertDenied(t, v.RemoveAll(dir))
+	})
+	t.Run("RootOwnedDir", func(t *testing.T) {
+		_, err := v.ReadDir("/")
+		assert.NoError(t, err)
+		assertDenied(t, v.Mkdir("/new", 0700))
+		assertDenied(t, v.Remove("/home"))
+	})
+	t.Run("ChmodErrNotOwner", func(t *testing.T) {
+		tmpRoot, err := v.Stat(testos.Join(tmpDir, ".."))
+		require.NoError(t, err)
+		assert.Equal(t, fs.ModeDir|fs.ModeSticky|0777, tmpRoot.Mode())
+		assertDenied(t, v.Chmod(testos.Join(tmpDir, ".."), 0700))
+	})
+	t.Run("Root", func(t *testing.T) {
+		r := vos.New(vos.WithUser(0, 0))
+		rootDir := vos.MkTempDir(r)
+		file := testos.Join(rootDir, "file")
+		require.NoError(t, r.WriteFile(file, []byte("some data"), 0))
+		require.NoError(t, r.Chmod(rootDir, 0))
+
+		testos.AssertFileData(t, r, file, "some data")
+		assert.NoError(t, r.WriteFile(file, nil, 0))
+	})
+}
+
+func TestSymlinkParentDir(t *testing.T) {
+	v := vos.New()
+	tmpDir := vos.MkTempDir(v)
+	testos.RequireMkdirAll(t, v, testos.Join(tmpDir, "a", "b"))
+	testos.RequireWrite(t, v, testos.Join(tmpDir, "a", "file"), "some data")
+	link := testos.Join(tmpDir, "link")
+	require.NoError(t, v.Symlink(testos.Join("a", "b"), link))
+
+	got, err := v.ReadFile(link + "/../file")
+	assert.NoError(t, err)
+	assert.Equal(t, "some data", string(got))
+}
+
+func TestHardLinks(t *testing.T) {
+	v := vos.New()
+	tmpDir := vos.MkTempDir(v)
+	file := testos.Join(tmpDir, "file")
+	testos.RequireWrite(t, v, file, "some data")
+
+	sys := func(t *testing.T, name string) *vos.FileStat {
+		t.Helper()
+		stat, err := v.Lstat(name)
+		require.NoError(t, err)
+		s, ok := stat.Sys().(*vos.FileStat)
+		require.True(t, ok)
+		return s
+	}
+	assert.Equal(t, 1, sys(t, file).Nlink)
+
+	link1 := testos.Join(tmpDir, "link1")
+	link2 := testos.Join(tmpDir, "link2")
+	require.NoError(t, v.Link(file, link1))
+	require.NoError(t, v.Link(link1, link2))
+	for _, name := range []string{file, link1, link2} {
+		assert.Equal(t, 3, sys(t, name).Nlink)
+		assert.Equal(t, sys(t, file).Ino, sys(t, name).Ino)
+	}
+
+	f, err := v.Open(link2)
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.NoError(t, v.Remove(file))
+	assert.Equal(t, 2, sys(t, link1).Nlink)
+	require.NoError(t, v.Rename(link1, link2))
+	assert.Equal(t, 2, sys(t, link1).Nlink, "want rename between links to be a no-op")
+	require.NoError(t, v.RemoveAll(link1))
+	assert.Equal(t, 1, sys(t, link2).Nlink)
+
+	other := testos.Join(tmpDir, "other")
+	testos.RequireWrite(t, v, other, "other data")
+	require.NoError(t, v.Rename(other, link2))
+	testos.AssertFileData(t, v, link2, "other data")
+	stat, err := f.Stat()
+	require.NoError(t, err)
+	assert.Zero(t, stat.Sys().(*vos.FileStat).Nlink, "want last link dropped")
+	got := make([]byte, 9)
+	_, err = f.Read(got)
+	assert.NoError(t, err)
+	assert.Equal(t, "some data", string(got), "want open handle to keep data")
+
+	dir := testos.Join(tmpDir, "dir")
+	testos.RequireMkdirAll(t, v, testos.Join(dir, "a"))
+	testos.RequireMkdirAll(t, v, testos.Join(dir, "b"))
+	assert.Equal(t, 4, sys(t, dir).Nlink)
+
+	o := vos.New()
+	oFile := testos.Join(vos.MkTempDir(o), "file")
+	testos.RequireEmptyWrite(t, o, oFile)
+	oStat, err := o.Stat(oFile)
+	require.NoError(t, err)
+	vStat, err := v.Stat(link2)
+	require.NoError(t, err)
+	assert.False(t, v.SameFile(vStat, oStat), "want files of different instances to differ")
+}
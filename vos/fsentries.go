@@ -2,13 +2,95 @@ package vos
 
 import (
 	"io/fs"
+	"time"
 )
 
 type dirEntry interface {
 	isDir() bool
 	size() int
 	isEmpty() bool
-	toFile(name string) (fs.File, error)
+	meta() *entryMeta
+	toFile(name string, flag int) (*fsFile, error)
+	clone() dirEntry
+}
+
+// entryMeta holds metadata common to all directory entries.
+type entryMeta struct {
+	perm  fs.FileMode
+	uid   int
+	gid   int
+	ino   uint64
+	nlink int
+
+	// gen is the generation of the file system that may modify the entry.
+	// Entries of other generations are shared with snapshots (see own).
+	gen uint64
+
+	atime time.Time
+	mtime time.Time
+	ctime time.Time
+}
+
+func (m *entryMeta) meta() *entryMeta {
+	return m
+}
+
+// modified marks the entry contents as modified at a given time.
+func (m *entryMeta) modified(t time.Time) {
+	m.mtime, m.ctime = t, t
+}
+
+// changed marks the entry metadata as changed at a given time.
+func (m *entryMeta) changed(t time.Time) {
+	m.ctime = t
+}
+
+// entryMode returns the file mode of a directory entry.
+func entryMode(e dirEntry) fs.FileMode {
+	mode := e.meta().perm
+	switch e.(type) {
+	case *vDir:
+		mode |= fs.ModeDir
+	case *vSymlink:
+		mode |= fs.ModeSymlink
+	}
+	return mode
+}
+
+// entryNlink returns the number of hard links to a directory entry.
+func entryNlink(e dirEntry) int {
+	dir, ok := e.(*vDir)
+	if !ok {
+		return e.meta().nlink
+	}
+	n := 2
+	for _, c := range dir.dirEntries {
+		if c.isDir() {
+			n++
+		}
+	}
+	return n
+}
+
+// newFileInfo returns a fsFileInfo describing a named directory entry of a
+// given device.
+func newFileInfo(name string, e dirEntry, dev uint64) fsFileInfo {
+	m := e.meta()
+	return fsFileInfo{
+		name:    name,
+		mode:    entryMode(e),
+		size:    int64(e.size()),
+		modTime: m.mtime,
+		sys: &FileStat{
+			Dev:   dev,
+			Ino:   m.ino,
+			Nlink: entryNlink(e),
+			Uid:   m.uid,
+			Gid:   m.gid,
+			Atime: m.atime,
+			Ctime: m.ctime,
+		},
+	}
 }
 
 type dirEntries map[string]dirEntry
@@ -60,11 +142,11 @@ func (es dirEntries) delete(name string) {
 	delete(es, name)
 }
 
-func (es dirEntries) list() []fsFileInfo {
+func (es dirEntries) list(dev uint64) []fsFileInfo {
 	contents := make([]fsFileInfo, len(es))
 	i := 0
 	for n, e := range es {
-		contents[i] = fsFileInfo{n, e.isDir(), int64(e.size())}
+		contents[i] = newFileInfo(n, e, dev)
 		i++
 	}
 	return contents
@@ -79,52 +161,129 @@ func (es dirEntries) isEmpty() bool {
 }
 
 type vDir struct {
+	entryMeta
 	dirEntries
 }
 
-func newVDir() vDir {
-	return vDir{make(dirEntries)}
+func newVDir(meta entryMeta) *vDir {
+	return &vDir{meta, make(dirEntries)}
 }
 
-func (vDir) isDir() bool {
+func (*vDir) isDir() bool {
 	return true
 }
 
-func (d vDir) toFile(name string) (fs.File, error) {
-	return &fsDir{
-		fsFile: &fsFile{
-			name: name,
-			data: nil,
-		},
-		contents: d.list(),
+func (d *vDir) clone() dirEntry {
+	c := newVDir(d.entryMeta)
+	for name, e := range d.dirEntries {
+		c.dirEntries[name] = e
+	}
+	return c
+}
+
+func (d *vDir) toFile(name string, flag int) (*fsFile, error) {
+	if flag&accessModes != 0 {
+		return nil, errIsDir
+	}
+	return &fsFile{
+		name:  name,
+		entry: d,
+		flag:  flag,
 	}, nil
 }
 
 type vFile struct {
+	entryMeta
 	data []byte
+	// shared reports whether data is shared with a cloned entry, requiring a
+	// copy before data is modified.
+	shared bool
 }
 
-func newVFile(data []byte) vFile {
+func newVFile(meta entryMeta, data []byte) *vFile {
 	if data == nil {
 		data = make([]byte, 0)
 	}
-	return vFile{data}
+	return &vFile{meta, data, false}
 }
 
-func (vFile) isDir() bool {
+func (*vFile) isDir() bool {
 	return false
 }
 
-func (f vFile) size() int {
+func (f *vFile) clone() dirEntry {
+	return &vFile{f.entryMeta, f.data, true}
+}
+
+// unshare copies shared file data so that it may be modified.
+func (f *vFile) unshare() {
+	if f.shared {
+		f.data = append([]byte{}, f.data...)
+		f.shared = false
+	}
+}
+
+func (f *vFile) size() int {
 	return len(f.data)
 }
-func (f vFile) isEmpty() bool {
+func (f *vFile) isEmpty() bool {
 	return f.size() == 0
 }
 
-func (f vFile) toFile(name string) (fs.File, error) {
+func (f *vFile) toFile(name string, flag int) (*fsFile, error) {
 	return &fsFile{
-		name: name,
-		data: f.data,
+		name:  name,
+		entry: f,
+		flag:  flag,
 	}, nil
 }
+
+// writeAt writes p at offset off, growing the file data as needed.
+func (f *vFile) writeAt(p []byte, off int64) {
+	f.unshare()
+	if end := off + int64(len(p)); end > int64(len(f.data)) {
+		f.truncate(end)
+	}
+	copy(f.data[off:], p)
+}
+
+// truncate changes the size of the file data, zero-filling any new bytes.
+func (f *vFile) truncate(size int64) {
+	f.unshare()
+	if l := int64(len(f.data)); size <= l {
+		f.data = f.data[:size]
+		return
+	}
+	// Grow via append, so that streaming writes take amortized linear time.
+	f.data = append(f.data, make([]byte, size-int64(len(f.data)))...)
+}
+
+type vSymlink struct {
+	entryMeta
+	target string
+}
+
+func newVSymlink(meta entryMeta, target string) *vSymlink {
+	return &vSymlink{meta, target}
+}
+
+func (*vSymlink) isDir() bool {
+	return false
+}
+
+func (l *vSymlink) clone() dirEntry {
+	c := *l
+	return &c
+}
+
+func (l *vSymlink) size() int {
+	return len(l.target)
+}
+
+func (l *vSymlink) isEmpty() bool {
+	return l.size() == 0
+}
+
+func (*vSymlink) toFile(name string, flag int) (*fsFile, error) {
+	return nil, errLoop
+}
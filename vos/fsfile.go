@@ -1,63 +1,276 @@
 package vos
 
 import (
-	"errors"
 	"io"
 	"io/fs"
+	"path/filepath"
 	"time"
 
 	"github.com/echocrow/osa"
 )
 
+// accessModes masks the access mode bits of OpenFile flags.
+const accessModes = osa.O_RDONLY | osa.O_WRONLY | osa.O_RDWR
+
+// fsFile represents an open file or directory.
 type fsFile struct {
+	fsys     *vfs
 	name     string
-	data     []byte
+	entry    dirEntry
+	flag     int
 	isClosed bool
+	boot     uint64
+	offset   int64
+	contents []fsFileInfo
 	read     int
 }
 
+// closed reports whether the handle was closed, either explicitly or by a
+// crash of its file system.
+func (f *fsFile) closed() bool {
+	return f.isClosed || f.boot != f.fsys.boot
+}
+
+// current returns the entry of the handle, following copies of the entry made
+// since the handle was opened (see vfs.own).
+func (f *fsFile) current() dirEntry {
+	f.entry = f.fsys.own(f.entry)
+	return f.entry
+}
+
+func (f *fsFile) Name() string {
+	return f.name
+}
+
 func (f *fsFile) Stat() (fs.FileInfo, error) {
-	return fsFileInfo{
-		name:  f.name,
-		isDir: f.data == nil,
-		size:  int64(len(f.data)),
-	}, nil
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if f.closed() {
+		return nil, f.err("stat", fs.ErrClosed)
+	}
+	return newFileInfo(filepath.Base(f.name), f.current(), f.fsys.dev), nil
 }
 
 func (f *fsFile) Read(to []byte) (int, error) {
-	if f.data == nil {
-		return 0, errNotFile
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	file, err := f.file("read", false)
+	if err != nil {
+		return 0, err
+	}
+	n, err := f.readAt(file, to, f.offset)
+	f.offset += int64(n)
+	file.atime = f.fsys.now()
+	return n, err
+}
+
+func (f *fsFile) ReadAt(to []byte, off int64) (int, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	file, err := f.file("read", false)
+	if err != nil {
+		return 0, err
 	}
-	if f.isClosed {
-		return 0, errors.New("not open")
+	if off < 0 {
+		return 0, f.err("readat", errNegativeOffset)
 	}
-	start := f.read
-	end := len(f.data)
-	if start >= end {
+	n, err := f.readAt(file, to, off)
+	file.atime = f.fsys.now()
+	if err == nil && n < len(to) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f *fsFile) readAt(file *vFile, to []byte, off int64) (int, error) {
+	if off >= int64(len(file.data)) {
 		return 0, io.EOF
 	}
-	l := end - start
-	if len(to) < l {
-		l = len(to)
+	return copy(to, file.data[off:]), nil
+}
+
+func (f *fsFile) Write(b []byte) (int, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	return f.write(b)
+}
+
+func (f *fsFile) write(b []byte) (int, error) {
+	file, err := f.file("write", true)
+	if err != nil {
+		return 0, err
+	}
+	if f.flag&osa.O_APPEND != 0 {
+		f.offset = int64(file.size())
+	}
+	n, err := f.fsys.reserveWrite(file, f.offset, len(b))
+	if n > 0 {
+		size := file.size()
+		file.writeAt(b[:n], f.offset)
+		f.fsys.growUsage(file, int64(file.size()-size))
+		file.modified(f.fsys.now())
+		f.offset += int64(n)
+	}
+	if err != nil {
+		return n, f.err("write", err)
 	}
-	copy(to, f.data[start:])
-	f.read += l
-	return l, nil
+	return n, nil
+}
+
+func (f *fsFile) WriteAt(b []byte, off int64) (int, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if f.flag&osa.O_APPEND != 0 {
+		return 0, errWriteAtInAppendMode
+	}
+	file, err := f.file("write", true)
+	if err != nil {
+		return 0, err
+	}
+	if off < 0 {
+		return 0, f.err("writeat", errNegativeOffset)
+	}
+	n, err := f.fsys.reserveWrite(file, off, len(b))
+	if n > 0 {
+		size := file.size()
+		file.writeAt(b[:n], off)
+		f.fsys.growUsage(file, int64(file.size()-size))
+		file.modified(f.fsys.now())
+	}
+	if err != nil {
+		return n, f.err("write", err)
+	}
+	return n, nil
+}
+
+func (f *fsFile) Seek(offset int64, whence int) (int64, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if f.closed() {
+		return 0, f.err("seek", fs.ErrClosed)
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(f.current().size())
+	case io.SeekStart:
+	default:
+		return 0, f.err("seek", fs.ErrInvalid)
+	}
+	if offset < 0 {
+		return 0, f.err("seek", fs.ErrInvalid)
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *fsFile) Truncate(size int64) error {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	file, err := f.file("truncate", true)
+	if err != nil {
+		if !f.closed() {
+			err = f.err("truncate", fs.ErrInvalid)
+		}
+		return err
+	}
+	if size < 0 {
+		return f.err("truncate", fs.ErrInvalid)
+	}
+	if n, err := f.fsys.reserveWrite(file, 0, int(size)); n < int(size) {
+		return f.err("truncate", err)
+	}
+	f.fsys.growUsage(file, size-int64(file.size()))
+	file.truncate(size)
+	file.modified(f.fsys.now())
+	return nil
+}
+
+func (f *fsFile) Sync() error {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if f.closed() {
+		return f.err("sync", fs.ErrClosed)
+	}
+	f.fsys.sync(f.current())
+	return nil
 }
 
 func (f *fsFile) Close() error {
-	if f.isClosed {
-		return errors.New("not open")
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if f.closed() {
+		return f.err("close", fs.ErrClosed)
 	}
 	f.isClosed = true
 	return nil
 }
 
+// ReadDir reads the contents of the directory and returns a slice of up to n
+// DirEntry values in directory order.
+//
+// See fs.ReadDirFile
+func (f *fsFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if f.closed() {
+		return nil, f.err("readdirent", fs.ErrClosed)
+	}
+	dir, ok := f.current().(*vDir)
+	if !ok {
+		return nil, f.err("readdirent", errNotDir)
+	}
+	if f.contents == nil {
+		f.contents = dir.list(f.fsys.dev)
+	}
+	start := f.read
+	l := len(f.contents) - start
+	if l <= 0 {
+		if n <= 0 {
+			return nil, nil
+		}
+		return nil, io.EOF
+	}
+	if 0 < n && n < l {
+		l = n
+	}
+	end := start + l
+	entries := make([]fs.DirEntry, l)
+	for i, c := range f.contents[start:end] {
+		entries[i] = c
+	}
+	f.read = end
+	return entries, nil
+}
+
+// file returns the underlying regular file if the handle permits the access.
+func (f *fsFile) file(op string, write bool) (*vFile, error) {
+	if f.closed() {
+		return nil, f.err(op, fs.ErrClosed)
+	}
+	file, ok := f.current().(*vFile)
+	if !ok {
+		return nil, f.err(op, errIsDir)
+	}
+	mode := f.flag & accessModes
+	if write && mode == osa.O_RDONLY || !write && mode == osa.O_WRONLY {
+		return nil, f.err(op, errBadFd)
+	}
+	return file, nil
+}
+
+func (f *fsFile) err(op string, err error) error {
+	return newPathError(op, f.name, err)
+}
+
 // fsFileInfo represents a fs.FileInfo and fs.DirEntry
 type fsFileInfo struct {
-	name  string
-	isDir bool
-	size  int64
+	name    string
+	mode    fs.FileMode
+	size    int64
+	modTime time.Time
+	sys     *FileStat
 }
 
 func (f fsFileInfo) Name() string {
@@ -69,54 +282,38 @@ func (f fsFileInfo) Size() int64 {
 }
 
 func (f fsFileInfo) Mode() osa.FileMode {
-	return 0
+	return f.mode
 }
 func (f fsFileInfo) Type() osa.FileMode {
-	return 0
+	return f.mode.Type()
 }
 
 func (f fsFileInfo) ModTime() time.Time {
-	return time.Time{}
+	return f.modTime
 }
 
 func (f fsFileInfo) IsDir() bool {
-	return f.isDir
+	return f.mode.IsDir()
 }
 
+// Sys returns the underlying *FileStat.
 func (f fsFileInfo) Sys() interface{} {
-	return nil
+	return f.sys
 }
 
 func (f fsFileInfo) Info() (fs.FileInfo, error) {
 	return f, nil
 }
 
-type fsDir struct {
-	*fsFile
-	contents []fsFileInfo
-}
-
-// ReadDir reads the contents of the directory and returns a slice of up to n
-// DirEntry values in directory order.
+// FileStat holds additional file metadata of vos files.
 //
-// See fs.ReadDirFile
-func (d *fsDir) ReadDir(n int) ([]fs.DirEntry, error) {
-	if d.isClosed {
-		return nil, errors.New("not open")
-	}
-	start := d.read
-	l := len(d.contents) - start
-	if l <= 0 {
-		return nil, io.EOF
-	}
-	if 0 < n && n < l {
-		l = n
-	}
-	end := start + l
-	entries := make([]fs.DirEntry, l)
-	for i, c := range d.contents[start:end] {
-		entries[i] = c
-	}
-	d.read = end
-	return entries, nil
+// It is returned by the Sys method of FileInfo values of vos.
+type FileStat struct {
+	Dev   uint64    // ID of the vos instance containing the file
+	Ino   uint64    // inode number
+	Nlink int       // number of hard links
+	Uid   int       // user ID of owner
+	Gid   int       // group ID of owner
+	Atime time.Time // time of last access
+	Ctime time.Time // time of last status change
 }
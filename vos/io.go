@@ -3,19 +3,20 @@ package vos
 import (
 	"bytes"
 	"io"
+	"sync"
 )
 
 type vosIO struct {
-	stdin  *bytes.Buffer
-	stdout *bytes.Buffer
-	stderr *bytes.Buffer
+	stdin  *syncBuffer
+	stdout *syncBuffer
+	stderr *syncBuffer
 }
 
 func newIO() vosIO {
 	return vosIO{
-		stdin:  new(bytes.Buffer),
-		stdout: new(bytes.Buffer),
-		stderr: new(bytes.Buffer),
+		stdin:  new(syncBuffer),
+		stdout: new(syncBuffer),
+		stderr: new(syncBuffer),
 	}
 }
 
@@ -42,3 +43,33 @@ func ClearStdio(v vos) {
 	v.stdout.Reset()
 	v.stderr.Reset()
 }
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Read(p)
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *syncBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
@@ -0,0 +1,19 @@
+package vos
+
+import (
+	"io/fs"
+
+	os "github.com/echocrow/osa"
+)
+
+// FS returns a file system for the tree of files rooted at the current working
+// directory of the vos instance, as returned by os.DirFS. Later changes of the
+// working directory do not affect the returned file system.
+//
+// As fs.FS requires, names of the file system are slash-separated paths
+// relative to its root. The methods of the vos instance itself take OS paths
+// instead, and must not be used as a fs.FS.
+func FS(v vos) fs.FS {
+	wd, _ := v.Getwd()
+	return os.DirFS(v, wd)
+}
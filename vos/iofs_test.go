@@ -0,0 +1,40 @@
+package vos_test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFS(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	testos.Tree{"a.txt": "a", "sub/b.txt": "b", "sub/c.md": "c"}.Write(t, v, dir)
+
+	require.NoError(t, v.Chdir(dir))
+	fsys := vos.FS(v)
+	require.NoError(t, v.Chdir("sub"))
+
+	assert.Implements(t, (*fs.StatFS)(nil), fsys)
+	assert.Implements(t, (*fs.ReadDirFS)(nil), fsys)
+	assert.Implements(t, (*fs.ReadFileFS)(nil), fsys)
+	assert.Implements(t, (*fs.SubFS)(nil), fsys)
+	assert.Implements(t, (*fs.GlobFS)(nil), fsys)
+	require.NoError(t, fstest.TestFS(fsys, "a.txt", "sub/b.txt", "sub/c.md"))
+
+	matches, err := fs.Glob(fsys, "sub/*.txt")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sub/b.txt"}, matches)
+
+	sub, err := fs.Sub(fsys, "sub")
+	require.NoError(t, err)
+	require.NoError(t, fstest.TestFS(sub, "b.txt", "c.md"))
+
+	_, err = fsys.Open(testos.Join(dir, "a.txt"))
+	assert.ErrorIs(t, err, fs.ErrInvalid)
+}
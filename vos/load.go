@@ -0,0 +1,95 @@
+package vos
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Load copies all files and directories of fsys into the directory dir of the
+// vos instance, e.g. from os.DirFS, embed.FS, fstest.MapFS, or zip.Reader.
+// Missing parent directories of dir are created as needed. Existing files are
+// overwritten.
+//
+// Modes and modification times of the copied entries are preserved where
+// fsys provides them. Entries without any permission bits, e.g. fstest.MapFS
+// entries without a Mode, are created with the default permissions 0666 or
+// 0777, subject to the umask. Symbolic links to files are copied as regular
+// files; symbolic links to directories are skipped.
+func Load(v vos, dir string, fsys fs.FS) error {
+	if err := v.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	return loadDir(v, dir, fsys, ".")
+}
+
+// LoadDir copies the real directory src into the directory dir of the vos
+// instance. See Load.
+//
+// LoadDir always reads from the real file system, regardless of patches of
+// the OS abstraction.
+func LoadDir(v vos, dir, src string) error {
+	return Load(v, dir, os.DirFS(src))
+}
+
+// loadDir copies the entries of directory name of fsys into directory dir.
+func loadDir(v vos, dir string, fsys fs.FS, name string) error {
+	entries, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		src := path.Join(name, e.Name())
+		dst := filepath.Join(dir, e.Name())
+		fi, err := fs.Stat(fsys, src)
+		if err != nil {
+			return err
+		}
+		switch {
+		case fi.IsDir() && e.Type()&fs.ModeSymlink != 0:
+			continue
+		case fi.IsDir():
+			// Keep the directory writable until its entries are copied.
+			if err := v.MkdirAll(dst, 0700); err != nil {
+				return err
+			}
+			if err := loadDir(v, dst, fsys, src); err != nil {
+				return err
+			}
+		case fi.Mode().IsRegular():
+			data, err := fs.ReadFile(fsys, src)
+			if err != nil {
+				return err
+			}
+			if err := v.WriteFile(dst, data, 0600); err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+		if err := loadMeta(v, dst, fi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadMeta applies the mode and modification time of fi to the named entry.
+func loadMeta(v vos, name string, fi fs.FileInfo) error {
+	perm := fi.Mode().Perm()
+	if perm == 0 {
+		perm = 0666
+		if fi.IsDir() {
+			perm = 0777
+		}
+		perm &^= v.umask
+	}
+	if err := v.Chmod(name, perm|fi.Mode()&modeMask); err != nil {
+		return err
+	}
+	if mtime := fi.ModTime(); !mtime.IsZero() {
+		return v.Chtimes(name, mtime, mtime)
+	}
+	return nil
+}
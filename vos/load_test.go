@@ -0,0 +1,115 @@
+package vos_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/echocrow/osa/oos"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	mtime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"a.txt":         {Data: []byte("a"), Mode: 0640, ModTime: mtime},
+		"ro/b.txt":      {Data: []byte("b"), Mode: 0444},
+		"ro":            {Mode: fs.ModeDir | 0555, ModTime: mtime},
+		"sub/dir/c.bin": {Data: []byte{0, 1}},
+	}
+	v := vos.New(vos.WithUmask(0022))
+	dir := testos.Join(vos.MkTempDir(v), "mnt")
+	require.NoError(t, vos.Load(v, dir, fsys))
+
+	testos.AssertFileData(t, v, testos.Join(dir, "a.txt"), "a")
+	testos.AssertFileData(t, v, testos.Join(dir, "ro", "b.txt"), "b")
+	testos.AssertFileData(t, v, testos.Join(dir, "sub", "dir", "c.bin"), "\x00\x01")
+
+	for _, tc := range []struct {
+		path  string
+		mode  fs.FileMode
+		mtime time.Time
+	}{
+		{"a.txt", 0640, mtime},
+		{"ro", fs.ModeDir | 0555, mtime},
+		{"ro/b.txt", 0444, time.Time{}},
+		{"sub", fs.ModeDir | 0555, time.Time{}}, // synthesized by MapFS
+		{"sub/dir/c.bin", 0644, time.Time{}},
+	} {
+		fi, err := v.Stat(testos.Join(dir, tc.path))
+		require.NoError(t, err, tc.path)
+		assert.Equal(t, tc.mode, fi.Mode(), tc.path)
+		if !tc.mtime.IsZero() {
+			assert.True(t, tc.mtime.Equal(fi.ModTime()), tc.path)
+		}
+	}
+}
+
+func TestLoadOverwrites(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	testos.RequireWrite(t, v, testos.Join(dir, "file"), "old")
+	testos.RequireWrite(t, v, testos.Join(dir, "keep"), "keep")
+	require.NoError(t, vos.Load(v, dir, fstest.MapFS{"file": {Data: []byte("new")}}))
+	testos.AssertFileData(t, v, testos.Join(dir, "file"), "new")
+	testos.AssertFileData(t, v, testos.Join(dir, "keep"), "keep")
+
+	testos.RequireWrite(t, v, testos.Join(dir, "dir"), "")
+	err := vos.Load(v, dir, fstest.MapFS{"dir/file": {}})
+	assert.Error(t, err)
+}
+
+func TestLoadZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	h := &zip.FileHeader{Name: "dir/file", Modified: time.Unix(1000, 0)}
+	h.SetMode(0750)
+	w, err := zw.CreateHeader(h)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("zipped"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	require.NoError(t, vos.Load(v, dir, zr))
+	name := testos.Join(dir, "dir", "file")
+	testos.AssertFileData(t, v, name, "zipped")
+	fi, err := v.Stat(name)
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0750), fi.Mode())
+	assert.True(t, time.Unix(1000, 0).Equal(fi.ModTime()))
+}
+
+func TestLoadDir(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "sub"), 0755))
+	require.NoError(t, os.Chmod(filepath.Join(src, "sub"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "sub", "file"), []byte("data"), 0644))
+	require.NoError(t, os.Chmod(filepath.Join(src, "sub", "file"), 0604))
+	require.NoError(t, os.Symlink("sub/file", filepath.Join(src, "filelink")))
+	require.NoError(t, os.Symlink("sub", filepath.Join(src, "dirlink")))
+
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	require.NoError(t, vos.LoadDir(v, dir, src))
+	testos.AssertFileData(t, v, testos.Join(dir, "filelink"), "data")
+	testos.AssertNotExists(t, v, testos.Join(dir, "dirlink"))
+
+	require.NoError(t, os.Remove(filepath.Join(src, "dirlink")))
+	require.NoError(t, os.Remove(filepath.Join(src, "filelink")))
+	require.NoError(t, v.Remove(testos.Join(dir, "filelink")))
+	changes, err := vos.DiffDirs(v, dir, oos.New(), src)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
@@ -0,0 +1,244 @@
+package vos
+
+import (
+	"path/filepath"
+	"syscall"
+)
+
+// WithCapacity limits the total size of file data and the total number of
+// inodes (files, directories, and symlinks) of the vos instance.
+//
+// Operations that would exceed a limit fail with ENOSPC. Writes that exceed
+// the byte limit write as many bytes as fit. A limit of zero or less means no
+// limit.
+func WithCapacity(bytes int64, inodes int) Option {
+	return func(c *config) {
+		c.limits = append(c.limits, limit{
+			dir:    string(filepath.Separator),
+			bytes:  bytes,
+			inodes: inodes,
+			err:    syscall.ENOSPC,
+		})
+	}
+}
+
+// WithQuota limits the size of file data and the number of inodes within the
+// directory subtree at dir, including dir itself.
+//
+// Operations that would exceed a limit fail with EDQUOT. Writes that exceed
+// the byte limit write as many bytes as fit. A limit of zero or less means no
+// limit. The quota applies once dir exists.
+func WithQuota(dir string, bytes int64, inodes int) Option {
+	return func(c *config) {
+		c.limits = append(c.limits, limit{
+			dir:    dir,
+			bytes:  bytes,
+			inodes: inodes,
+			err:    syscall.EDQUOT,
+		})
+	}
+}
+
+// limit describes a capacity limit of a directory subtree.
+type limit struct {
+	dir    string
+	bytes  int64
+	inodes int
+	err    error
+}
+
+// DiskUsage describes the consumption of a directory subtree.
+type DiskUsage struct {
+	Bytes  int64 // total size of file data
+	Inodes int   // number of files, directories, and symlinks
+}
+
+// Usage returns the current consumption of the directory subtree at dir,
+// including dir itself.
+//
+// Files with multiple hard links within the subtree are counted once.
+func Usage(v vos, dir string) (DiskUsage, error) {
+	v.vfs.mu.Lock()
+	defer v.vfs.mu.Unlock()
+	d, err := v.getDir(dir)
+	if err != nil {
+		return DiskUsage{}, newPathError("usage", dir, err)
+	}
+	u, _ := v.usage(d)
+	return u, nil
+}
+
+// usage returns the consumption of the subtree of a directory entry, as well
+// as the set of entries within it.
+func (v *vfs) usage(e dirEntry) (DiskUsage, map[dirEntry]bool) {
+	var u DiskUsage
+	seen := make(map[dirEntry]bool)
+	var walk func(e dirEntry)
+	walk = func(e dirEntry) {
+		if seen[e] {
+			return
+		}
+		seen[e] = true
+		u.Inodes++
+		switch e := e.(type) {
+		case *vFile:
+			u.Bytes += int64(e.size())
+		case *vDir:
+			for _, c := range e.dirEntries {
+				walk(v.latest(c))
+			}
+		}
+	}
+	walk(e)
+	return u, seen
+}
+
+// subtreeUsage is the cached consumption of the directory subtree of a limit.
+type subtreeUsage struct {
+	root   *vDir
+	usage  DiskUsage
+	within map[dirEntry]bool
+}
+
+// limitUsage returns the consumption of the subtree of the limit at index i,
+// or nil if its directory does not exist.
+//
+// The consumption is computed once, and then kept up to date by addUsage and
+// growUsage. Changes that are harder to track, such as removals and renames,
+// drop all cached consumption via dropUsage instead.
+func (v *vfs) limitUsage(i int) *subtreeUsage {
+	root, err := v.getDir(v.limits[i].dir)
+	if err != nil {
+		return nil
+	}
+	if v.usages == nil {
+		v.usages = make([]*subtreeUsage, len(v.limits))
+	}
+	if su := v.usages[i]; su != nil && su.root == root {
+		return su
+	}
+	u, within := v.usage(root)
+	su := &subtreeUsage{root, u, within}
+	v.usages[i] = su
+	return su
+}
+
+// addUsage accounts for a new file, symlink, or empty directory, or a new
+// hard link to a file, within a directory.
+func (v *vfs) addUsage(dir *vDir, e dirEntry) {
+	for _, su := range v.usages {
+		if su == nil || !su.within[dir] || su.within[e] {
+			continue
+		}
+		su.within[e] = true
+		su.usage.Inodes++
+		if f, ok := e.(*vFile); ok {
+			su.usage.Bytes += int64(f.size())
+		}
+	}
+}
+
+// growUsage accounts for a file that changed its size by a number of bytes.
+func (v *vfs) growUsage(file *vFile, bytes int64) {
+	if bytes == 0 {
+		return
+	}
+	for _, su := range v.usages {
+		if su != nil && su.within[file] {
+			su.usage.Bytes += bytes
+		}
+	}
+}
+
+// dropUsage drops all cached consumption.
+func (v *vfs) dropUsage() {
+	v.usages = nil
+}
+
+// reserve checks whether the subtrees containing a target entry may grow by a
+// number of bytes and inodes. Subtrees that also contain the from entry are
+// skipped.
+//
+// It returns the number of bytes that fit, and the error of the first limit
+// that would be exceeded. When inodes do not fit, no bytes fit either.
+func (v *vfs) reserve(target, from dirEntry, bytes int64, inodes int) (
+	int64,
+	error,
+) {
+	fit := bytes
+	var err error
+	for i, l := range v.limits {
+		su := v.limitUsage(i)
+		if su == nil {
+			continue
+		}
+		u, within := su.usage, su.within
+		if !within[target] || from != nil && within[from] {
+			continue
+		}
+		if l.inodes > 0 && u.Inodes+inodes > l.inodes {
+			return 0, l.err
+		}
+		if l.bytes <= 0 {
+			continue
+		}
+		if avail := l.bytes - u.Bytes; avail < fit {
+			fit = avail
+			if fit < 0 {
+				fit = 0
+			}
+			if err == nil {
+				err = l.err
+			}
+		}
+	}
+	return fit, err
+}
+
+// reserveEntry checks whether a new entry may be added to a directory.
+func (v *vfs) reserveEntry(dir *vDir) error {
+	if len(v.limits) == 0 {
+		return nil
+	}
+	_, err := v.reserve(dir, nil, 0, 1)
+	return err
+}
+
+// reserveWrite returns the number of bytes of a write at an offset that fit a
+// file, and the error of the first limit that would be exceeded.
+func (v *vfs) reserveWrite(file *vFile, off int64, n int) (int, error) {
+	grow := off + int64(n) - int64(file.size())
+	if grow <= 0 || len(v.limits) == 0 {
+		return n, nil
+	}
+	fit, err := v.reserve(file, nil, grow, 0)
+	if err == nil {
+		return n, nil
+	}
+	fitN := int64(n) - (grow - fit)
+	if fitN < 0 {
+		fitN = 0
+	}
+	return int(fitN), err
+}
+
+// reserveMove checks whether an entry may move from one directory to another.
+func (v *vfs) reserveMove(e dirEntry, from, to *vDir) error {
+	if len(v.limits) == 0 {
+		return nil
+	}
+	u, _ := v.usage(e)
+	_, err := v.reserve(to, from, u.Bytes, u.Inodes)
+	return err
+}
+
+// reserveLink checks whether a hard link to an entry may be added to a
+// directory. Subtrees that already contain the entry do not grow.
+func (v *vfs) reserveLink(e dirEntry, dir *vDir) error {
+	if len(v.limits) == 0 {
+		return nil
+	}
+	u, _ := v.usage(e)
+	_, err := v.reserve(dir, e, u.Bytes, u.Inodes)
+	return err
+}
@@ -0,0 +1,180 @@
+package vos_test
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapacityBytes(t *testing.T) {
+	clock := vos.NewFakeClock(time.Unix(1000, 0))
+	v := vos.New(vos.WithCapacity(10, 0), vos.WithClock(clock.Now))
+	dir := vos.MkTempDir(v)
+	file := testos.Join(dir, "file")
+
+	require.NoError(t, v.WriteFile(file, []byte("0123456"), 0644))
+
+	f, err := v.OpenFile(file, osa.O_WRONLY|osa.O_APPEND, 0)
+	require.NoError(t, err)
+	defer f.Close()
+	n, err := f.Write([]byte("789abc"))
+	assert.Equal(t, 3, n)
+	assert.True(t, errors.Is(err, syscall.ENOSPC), err)
+	testos.AssertFileData(t, v, file, "0123456789")
+
+	// Writes that do not fit at all leave the modification time as is.
+	clock.Set(time.Unix(2000, 0))
+	n, err = f.Write([]byte("d"))
+	assert.Zero(t, n)
+	assert.True(t, errors.Is(err, syscall.ENOSPC), err)
+	fi, err := v.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1000, 0), fi.ModTime())
+
+	err = v.WriteFile(testos.Join(dir, "other"), []byte("data"), 0644)
+	assert.True(t, errors.Is(err, syscall.ENOSPC), err)
+	testos.AssertFileData(t, v, testos.Join(dir, "other"), "")
+
+	require.NoError(t, v.WriteFile(file, []byte("01"), 0644))
+	f2, err := v.OpenFile(file, osa.O_WRONLY, 0)
+	require.NoError(t, err)
+	defer f2.Close()
+	assert.True(t, errors.Is(f2.Truncate(11), syscall.ENOSPC))
+	assert.NoError(t, f2.Truncate(10))
+
+	clock.Set(time.Unix(3000, 0))
+	n, err = f2.WriteAt([]byte("e"), 10)
+	assert.Zero(t, n)
+	assert.True(t, errors.Is(err, syscall.ENOSPC), err)
+	fi, err = v.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(2000, 0), fi.ModTime())
+}
+
+func TestCapacityInodes(t *testing.T) {
+	v := vos.New()
+	u, err := vos.Usage(v, "/")
+	require.NoError(t, err)
+
+	v = vos.New(vos.WithCapacity(0, u.Inodes+2))
+	dir := "/dir"
+	require.NoError(t, v.Mkdir(dir, 0755))
+	require.NoError(t, v.WriteFile(testos.Join(dir, "file"), nil, 0644))
+
+	err = v.WriteFile(testos.Join(dir, "other"), nil, 0644)
+	assert.True(t, errors.Is(err, syscall.ENOSPC), err)
+	err = v.Mkdir(testos.Join(dir, "sub"), 0755)
+	assert.True(t, errors.Is(err, syscall.ENOSPC), err)
+	err = v.Symlink("file", testos.Join(dir, "link"))
+	assert.True(t, errors.Is(err, syscall.ENOSPC), err)
+
+	require.NoError(t, v.Link(testos.Join(dir, "file"), testos.Join(dir, "hardlink")))
+	require.NoError(t, v.Remove(testos.Join(dir, "file")))
+	require.NoError(t, v.Remove(testos.Join(dir, "hardlink")))
+	assert.NoError(t, v.WriteFile(testos.Join(dir, "other"), nil, 0644))
+}
+
+func TestQuota(t *testing.T) {
+	v := vos.New(vos.WithQuota("/data", 8, 3))
+	require.NoError(t, v.Mkdir("/data", 0755))
+	require.NoError(t, v.WriteFile("/outside", []byte(strings.Repeat("x", 100)), 0644))
+
+	require.NoError(t, v.WriteFile("/data/a", []byte("0123"), 0644))
+	err := v.WriteFile("/data/b", []byte("456789"), 0644)
+	assert.True(t, errors.Is(err, syscall.EDQUOT), err)
+	testos.AssertFileData(t, v, "/data/b", "4567")
+
+	err = v.WriteFile("/data/c", nil, 0644)
+	assert.True(t, errors.Is(err, syscall.EDQUOT), err)
+
+	err = v.Rename("/outside", "/data/a")
+	assert.True(t, errors.Is(err, syscall.EDQUOT), err)
+	err = v.Link("/outside", "/data/link")
+	assert.True(t, errors.Is(err, syscall.EDQUOT), err)
+	testos.AssertNotExists(t, v, "/data/link")
+	require.NoError(t, v.Link("/data/a", "/data/link"))
+	require.NoError(t, v.Rename("/data/b", "/data/c"))
+	require.NoError(t, v.Rename("/data/c", "/moved"))
+
+	u, err := vos.Usage(v, "/data")
+	require.NoError(t, err)
+	assert.Equal(t, vos.DiskUsage{Bytes: 4, Inodes: 2}, u)
+}
+
+func TestUsage(t *testing.T) {
+	v := vos.New()
+	dir := vos.MkTempDir(v)
+	testos.RequireWrite(t, v, testos.Join(dir, "a"), "abc")
+	testos.RequireMkdir(t, v, testos.Join(dir, "sub"))
+	testos.RequireWrite(t, v, testos.Join(dir, "sub", "b"), "de")
+	require.NoError(t, v.Link(testos.Join(dir, "a"), testos.Join(dir, "sub", "c")))
+
+	u, err := vos.Usage(v, dir)
+	require.NoError(t, err)
+	assert.Equal(t, vos.DiskUsage{Bytes: 5, Inodes: 4}, u)
+
+	_, err = vos.Usage(v, testos.Join(dir, "missing"))
+	assert.True(t, v.IsNotExist(err))
+}
+
+func TestQuotaTracksChanges(t *testing.T) {
+	v := vos.New(vos.WithQuota("/data", 10, 5))
+	require.NoError(t, v.Mkdir("/data", 0755))
+
+	require.NoError(t, v.WriteFile("/data/a", []byte("01234567"), 0644))
+	require.NoError(t, v.WriteFile("/data/a", []byte("01"), 0644))
+	require.NoError(t, v.WriteFile("/data/b", []byte("23456789"), 0644))
+	err := v.WriteFile("/data/c", []byte("x"), 0644)
+	assert.True(t, errors.Is(err, syscall.EDQUOT), err)
+
+	f, err := v.OpenFile("/data/b", osa.O_WRONLY, 0)
+	require.NoError(t, err)
+	require.NoError(t, f.Truncate(4))
+	require.NoError(t, f.Close())
+	require.NoError(t, v.WriteFile("/data/c", []byte("x"), 0644))
+
+	s := vos.Snapshot(v)
+	require.NoError(t, v.Remove("/data/b"))
+	require.NoError(t, v.Rename("/data/c", "/c"))
+	require.NoError(t, v.WriteFile("/data/d", []byte("45678901"), 0644))
+	u, err := vos.Usage(v, "/data")
+	require.NoError(t, err)
+	assert.Equal(t, vos.DiskUsage{Bytes: 10, Inodes: 3}, u)
+
+	vos.Restore(v, s)
+	err = v.WriteFile("/data/d", []byte("45678901"), 0644)
+	assert.True(t, errors.Is(err, syscall.EDQUOT), err)
+	testos.AssertFileData(t, v, "/data/d", "456")
+}
+
+func BenchmarkCapacityWrite(b *testing.B) {
+	v := vos.New(vos.WithCapacity(1<<40, 0))
+	dir := vos.MkTempDir(v)
+	for i := 0; i < 1000; i++ {
+		name := testos.Join(dir, strconv.Itoa(i))
+		if err := v.WriteFile(name, []byte("some data"), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	f, err := v.Create(testos.Join(dir, "out"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	data := []byte("x")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := f.Write(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
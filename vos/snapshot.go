@@ -0,0 +1,207 @@
+package vos
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// gens counts the file system generations created, providing unique
+// generation IDs.
+var gens uint64
+
+func newGen() uint64 {
+	return atomic.AddUint64(&gens, 1)
+}
+
+// State is an immutable snapshot of the file system and environment of a vos
+// instance. See Snapshot.
+type State struct {
+	entries *vDir
+	pwd     string
+	inos    uint64
+	durable map[dirEntry]*durableState
+	clones  map[dirEntry]dirEntry
+	env     map[string]string
+}
+
+// Snapshot returns a snapshot of the file system and environment of the vos
+// instance, including its working directory and durable state (see Sync).
+// Stdio and open files are not part of the snapshot.
+//
+// Taking a snapshot copies no files or directories. Instead, the snapshot
+// shares them with the instance, which copies each entry on first access
+// after, including reads via open files. The first change after a snapshot
+// also copies the bookkeeping of such copies, which grows with the number of
+// entries copied since the instance was created.
+func Snapshot(v vos) State {
+	v.vfs.mu.Lock()
+	defer v.vfs.mu.Unlock()
+	return State{
+		entries: v.vfs.snapshot(),
+		pwd:     v.pwd,
+		inos:    v.inos,
+		durable: v.durable,
+		clones:  v.clones,
+		env:     v.vosEnv.copyEnv(),
+	}
+}
+
+// Restore reverts the file system and environment of the vos instance to a
+// snapshot. The snapshot may stem from another instance.
+//
+// All open files are closed. Stdio and options of the instance, such as its
+// user or capacity limits, are kept.
+func Restore(v vos, s State) {
+	v.vfs.mu.Lock()
+	defer v.vfs.mu.Unlock()
+	v.boot++
+	v.restoreState(s)
+	v.vosEnv.replaceEnv(s.env)
+}
+
+// Fork returns a copy of the vos instance.
+//
+// The copy starts with the file system and environment of the instance, and
+// shares its options, such as its user, clock, and capacity limits. Stdio of
+// the copy starts out empty. Afterwards, the copy and the instance are fully
+// independent.
+//
+// Forking copies no files or directories; see Snapshot.
+func Fork(v vos) vos {
+	s := Snapshot(v)
+	v.vfs.mu.Lock()
+	fsys := &vfs{
+		temp:   v.temp,
+		home:   v.home,
+		usrCch: v.usrCch,
+		usrCfg: v.usrCfg,
+		umask:  v.umask,
+		user:   v.user,
+		now:    v.now,
+		limits: v.limits,
+		dev:    atomic.AddUint64(&devs, 1),
+	}
+	v.vfs.mu.Unlock()
+	fsys.restoreState(s)
+	env := vosEnv{mu: new(sync.RWMutex), env: make(map[string]string)}
+	env.replaceEnv(s.env)
+	return vos{
+		vosFS:  vosFS{fsys},
+		vosIO:  newIO(),
+		vosEnv: env,
+	}
+}
+
+// snapshot returns the root directory of the file system, to be shared with
+// a snapshot.
+//
+// All current entries become shared, as the file system moves on to a new
+// generation.
+func (v *vfs) snapshot() *vDir {
+	v.gen = newGen()
+	v.sharedMaps = true
+	return v.entries
+}
+
+// restoreState reverts the file system to a snapshot.
+func (v *vfs) restoreState(s State) {
+	v.gen = newGen()
+	v.entries = s.entries
+	v.pwd = s.pwd
+	v.inos = s.inos
+	v.durable = s.durable
+	v.clones = s.clones
+	v.sharedMaps = true
+	v.dropUsage()
+}
+
+// own returns the version of an entry that the file system may modify.
+//
+// Entries of other generations are shared with snapshots and must not be
+// modified. Instead, own copies such entries on first access, and tracks
+// their copies to keep hard links and open files intact.
+func (v *vfs) own(e dirEntry) dirEntry {
+	e = v.latest(e)
+	if e.meta().gen == v.gen {
+		return e
+	}
+	v.unshareMaps()
+	// Cached usage refers to entries by identity.
+	v.dropUsage()
+	c := e.clone()
+	c.meta().gen = v.gen
+	v.clones[e] = c
+	if d, ok := v.durable[e]; ok {
+		v.durable[c] = d
+	}
+	return c
+}
+
+// ownChild returns the version of a named directory entry that the file
+// system may modify, replacing the entry in its directory as needed. The
+// directory must be owned by the file system.
+func (v *vfs) ownChild(dir *vDir, name string, e dirEntry) dirEntry {
+	c := v.own(e)
+	if c != e {
+		dir.dirEntries[name] = c
+	}
+	return c
+}
+
+// root returns the root directory, owned by the file system.
+func (v *vfs) root() *vDir {
+	v.entries = v.own(v.entries).(*vDir)
+	return v.entries
+}
+
+// latest returns the most recent copy of an entry.
+func (v *vfs) latest(e dirEntry) dirEntry {
+	for {
+		c, ok := v.clones[e]
+		if !ok {
+			return e
+		}
+		e = c
+	}
+}
+
+// unshareMaps copies maps shared with snapshots so that they may be modified.
+// Chains of copies are collapsed, mapping each entry to its latest copy.
+func (v *vfs) unshareMaps() {
+	if !v.sharedMaps {
+		return
+	}
+	durable := make(map[dirEntry]*durableState, len(v.durable))
+	for e, d := range v.durable {
+		durable[e] = d
+	}
+	clones := make(map[dirEntry]dirEntry, len(v.clones))
+	for e := range v.clones {
+		clones[e] = v.latest(e)
+	}
+	v.durable, v.clones = durable, clones
+	v.sharedMaps = false
+}
+
+// copyEnv returns a copy of the environment.
+func (v vosEnv) copyEnv() map[string]string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	env := make(map[string]string, len(v.env))
+	for key, val := range v.env {
+		env[key] = val
+	}
+	return env
+}
+
+// replaceEnv replaces the environment with a copy of env.
+func (v vosEnv) replaceEnv(env map[string]string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for key := range v.env {
+		delete(v.env, key)
+	}
+	for key, val := range env {
+		v.env[key] = val
+	}
+}
@@ -0,0 +1,212 @@
+package vos_test
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"syscall"
+	"testing"
+
+	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/testos"
+	"github.com/echocrow/osa/vos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixture creates a small file tree in dir.
+func fixture(t *testing.T, o osa.I, dir string) {
+	testos.RequireMkdirAll(t, o, testos.Join(dir, "a", "b"))
+	testos.RequireWrite(t, o, testos.Join(dir, "a", "file"), "a")
+	testos.RequireWrite(t, o, testos.Join(dir, "a", "b", "file"), "b")
+	require.NoError(t, o.Symlink("a/file", testos.Join(dir, "link")))
+	require.NoError(t, o.Setenv("KEY", "val"))
+}
+
+// assertFixture asserts that an instance holds the tree of fixture.
+func assertFixture(t *testing.T, o osa.I, dir string) {
+	testos.AssertFileData(t, o, testos.Join(dir, "a", "file"), "a")
+	testos.AssertFileData(t, o, testos.Join(dir, "a", "b", "file"), "b")
+	testos.AssertFileData(t, o, testos.Join(dir, "link"), "a")
+	entries, err := o.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "val", o.Getenv("KEY"))
+}
+
+// mutate modifies the tree of fixture.
+func mutate(t *testing.T, o osa.I, dir string) {
+	testos.RequireWrite(t, o, testos.Join(dir, "a", "file"), "changed")
+	f, err := o.OpenFile(testos.Join(dir, "a", "b", "file"), osa.O_WRONLY|osa.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("+"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, o.Remove(testos.Join(dir, "link")))
+	require.NoError(t, o.Chmod(testos.Join(dir, "a"), 0755))
+	testos.RequireWrite(t, o, testos.Join(dir, "new"), "new")
+	require.NoError(t, o.Setenv("KEY", "changed"))
+	require.NoError(t, o.Chdir(dir))
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	o := vos.New()
+	dir := vos.MkTempDir(o)
+	fixture(t, o, dir)
+	home, err := o.Getwd()
+	require.NoError(t, err)
+
+	s := vos.Snapshot(o)
+	for i := 0; i < 2; i++ {
+		mutate(t, o, dir)
+		testos.AssertFileData(t, o, testos.Join(dir, "a", "file"), "changed")
+		testos.AssertFileData(t, o, testos.Join(dir, "a", "b", "file"), "b+")
+
+		vos.Restore(o, s)
+		assertFixture(t, o, dir)
+		testos.AssertNotExists(t, o, testos.Join(dir, "new"))
+		fi, err := o.Stat(testos.Join(dir, "a"))
+		require.NoError(t, err)
+		assert.Equal(t, fs.FileMode(0700), fi.Mode().Perm())
+		wd, err := o.Getwd()
+		require.NoError(t, err)
+		assert.Equal(t, home, wd)
+	}
+}
+
+func TestRestoreClosesFiles(t *testing.T) {
+	o := vos.New()
+	dir := vos.MkTempDir(o)
+	fixture(t, o, dir)
+	s := vos.Snapshot(o)
+	f, err := o.OpenFile(testos.Join(dir, "a", "file"), osa.O_RDWR, 0)
+	require.NoError(t, err)
+
+	vos.Restore(o, s)
+	_, err = f.Write([]byte("x"))
+	assert.ErrorIs(t, err, fs.ErrClosed)
+	assertFixture(t, o, dir)
+}
+
+func TestSnapshotOpenFile(t *testing.T) {
+	o := vos.New()
+	dir := vos.MkTempDir(o)
+	fixture(t, o, dir)
+	file := testos.Join(dir, "a", "file")
+	f, err := o.OpenFile(file, osa.O_RDWR, 0)
+	require.NoError(t, err)
+	defer f.Close()
+
+	s := vos.Snapshot(o)
+	_, err = f.WriteAt([]byte("x"), 1)
+	require.NoError(t, err)
+	testos.AssertFileData(t, o, file, "ax")
+	testos.RequireWrite(t, o, file, "y")
+	buf := make([]byte, 2)
+	n, err := f.ReadAt(buf, 0)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, "y", string(buf[:n]))
+
+	vos.Restore(o, s)
+	testos.AssertFileData(t, o, file, "a")
+}
+
+func TestFork(t *testing.T) {
+	o := vos.New()
+	dir := vos.MkTempDir(o)
+	fixture(t, o, dir)
+	fork := vos.Fork(o)
+	assertFixture(t, fork, dir)
+
+	mutate(t, fork, dir)
+	assertFixture(t, o, dir)
+	testos.AssertNotExists(t, o, testos.Join(dir, "new"))
+
+	testos.RequireWrite(t, o, testos.Join(dir, "a", "b", "file"), "base")
+	testos.AssertFileData(t, fork, testos.Join(dir, "a", "b", "file"), "b+")
+	require.NoError(t, o.Unsetenv("KEY"))
+	assert.Equal(t, "changed", fork.Getenv("KEY"))
+
+	fmt.Fprint(o.Stdout(), "out")
+	_, stdout, _ := vos.GetStdio(fork)
+	out, err := io.ReadAll(stdout)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestForkHardLinks(t *testing.T) {
+	o := vos.New()
+	dir := vos.MkTempDir(o)
+	fixture(t, o, dir)
+	file := testos.Join(dir, "a", "file")
+	link := testos.Join(dir, "a", "b", "hardlink")
+	require.NoError(t, o.Link(file, link))
+
+	fork := vos.Fork(o)
+	testos.RequireWrite(t, fork, link, "linked")
+	testos.AssertFileData(t, fork, file, "linked")
+	testos.AssertFileData(t, o, file, "a")
+
+	fi1, err := fork.Stat(file)
+	require.NoError(t, err)
+	fi2, err := fork.Stat(link)
+	require.NoError(t, err)
+	assert.True(t, fork.SameFile(fi1, fi2))
+	assert.Equal(t, 2, fi1.Sys().(*vos.FileStat).Nlink)
+}
+
+func TestForkParallel(t *testing.T) {
+	o := vos.New()
+	dir := vos.MkTempDir(o)
+	fixture(t, o, dir)
+	for i := 0; i < workers; i++ {
+		i := i
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+			fork := vos.Fork(o)
+			assertFixture(t, fork, dir)
+			data := fmt.Sprint(i)
+			testos.RequireWrite(t, fork, testos.Join(dir, "a", "file"), data)
+			require.NoError(t, fork.RemoveAll(testos.Join(dir, "a", "b")))
+			testos.AssertFileData(t, fork, testos.Join(dir, "link"), data)
+		})
+	}
+	t.Cleanup(func() {
+		assertFixture(t, o, dir)
+	})
+}
+
+func TestForkCrash(t *testing.T) {
+	o := vos.New()
+	dir := vos.MkTempDir(o)
+	fixture(t, o, dir)
+	vos.Sync(o)
+	fork := vos.Fork(o)
+	testos.RequireWrite(t, fork, testos.Join(dir, "a", "file"), "unsynced")
+
+	vos.Crash(fork)
+	testos.AssertFileData(t, fork, testos.Join(dir, "a", "file"), "a")
+	testos.AssertFileData(t, o, testos.Join(dir, "a", "file"), "a")
+}
+
+func TestForkQuota(t *testing.T) {
+	v := vos.New(vos.WithCapacity(0, 20))
+	dir := vos.MkTempDir(v)
+	fixture(t, v, dir)
+	before, err := vos.Usage(v, dir)
+	require.NoError(t, err)
+
+	fork := vos.Fork(v)
+	after, err := vos.Usage(fork, dir)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	for i := 0; ; i++ {
+		err := fork.Mkdir(testos.Join(dir, fmt.Sprint(i)), 0755)
+		if err != nil {
+			assert.ErrorIs(t, err, syscall.ENOSPC)
+			break
+		}
+	}
+	require.NoError(t, v.Mkdir(testos.Join(dir, "base"), 0755))
+}
@@ -0,0 +1,167 @@
+package vos
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around changes in a unified
+// diff.
+const diffContext = 3
+
+// maxLCS limits the size of the table used to find the longest common
+// subsequence of lines. Larger diffs replace all differing lines.
+const maxLCS = 1 << 22
+
+// lineOp is a line of a unified diff, prefixed by ' ', '-', or '+'.
+type lineOp struct {
+	kind byte
+	line string
+}
+
+// splitLines splits s into lines, keeping their line breaks.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the operations turning lines a into lines b.
+func diffLines(a, b []string) []lineOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre &&
+		a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	var ops []lineOp
+	for _, l := range a[:pre] {
+		ops = append(ops, lineOp{' ', l})
+	}
+	ops = append(ops, diffLCS(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, lineOp{' ', l})
+	}
+	return ops
+}
+
+// diffLCS returns the operations turning lines a into lines b, based on their
+// longest common subsequence.
+func diffLCS(a, b []string) []lineOp {
+	n, m := len(a), len(b)
+	var ops []lineOp
+	if n*m > maxLCS {
+		for _, l := range a {
+			ops = append(ops, lineOp{'-', l})
+		}
+		for _, l := range b {
+			ops = append(ops, lineOp{'+', l})
+		}
+		return ops
+	}
+	// lcs[i][j] holds the length of the LCS of a[i:] and b[j:].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, lineOp{' ', a[i]})
+			i++
+			j++
+		case j == m || i < n && lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, lineOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, lineOp{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// writeUnified renders the hunks of a unified diff of lines a and b.
+func writeUnified(w *strings.Builder, a, b []string) {
+	ops := diffLines(a, b)
+	n := len(ops)
+	include := make([]bool, n)
+	aPos, bPos := make([]int, n), make([]int, n)
+	aLine, bLine := 1, 1
+	for i, op := range ops {
+		aPos[i], bPos[i] = aLine, bLine
+		if op.kind != '+' {
+			aLine++
+		}
+		if op.kind != '-' {
+			bLine++
+		}
+		if op.kind == ' ' {
+			continue
+		}
+		for j := i - diffContext; j <= i+diffContext; j++ {
+			if 0 <= j && j < n {
+				include[j] = true
+			}
+		}
+	}
+	for i := 0; i < n; {
+		if !include[i] {
+			i++
+			continue
+		}
+		j := i
+		aLen, bLen := 0, 0
+		for ; j < n && include[j]; j++ {
+			if ops[j].kind != '+' {
+				aLen++
+			}
+			if ops[j].kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(w, "@@ -%s +%s @@\n",
+			hunkRange(aPos[i], aLen),
+			hunkRange(bPos[i], bLen),
+		)
+		for _, op := range ops[i:j] {
+			w.WriteByte(op.kind)
+			w.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				w.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = j
+	}
+}
+
+// hunkRange formats the line range of a hunk.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		start--
+	}
+	if n == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
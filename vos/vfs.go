@@ -5,6 +5,11 @@ import (
 	"io/fs"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	os "github.com/echocrow/osa"
 )
 
 var (
@@ -12,9 +17,30 @@ var (
 	errNotFile  = errors.New("not a file")
 	errNotEmpty = errors.New("not empty")
 	errOpFailed = errors.New("operation failed")
+	errIsDir    = errors.New("is a directory")
+	errLoop     = errors.New("too many levels of symbolic links")
+	errBadFd    = errors.New("bad file descriptor")
+
+	errNegativeOffset      = errors.New("negative offset")
+	errWriteAtInAppendMode = errors.New("invalid use of WriteAt on file opened with O_APPEND")
+)
+
+// Permission bits requested during access checks.
+const (
+	permR fs.FileMode = 04
+	permW fs.FileMode = 02
+	permX fs.FileMode = 01
 )
 
+// modeMask masks the mode bits that may be set via Chmod.
+const modeMask = fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+
+// devs counts the vfs instances created, providing unique device IDs.
+var devs uint64
+
 type vfs struct {
+	mu sync.Mutex
+
 	temp string
 
 	home   string
@@ -23,95 +49,465 @@ type vfs struct {
 
 	pwd string
 
-	entries vDir
+	umask  fs.FileMode
+	user   *vUser
+	now    func() time.Time
+	limits []limit
+	// usages caches the usage of limited subtrees by limit. See limitUsage.
+	usages []*subtreeUsage
+
+	dev  uint64
+	inos uint64
+
+	entries *vDir
+
+	// boot counts crashes, invalidating handles opened before a crash.
+	boot uint64
+	// durable holds the state of entries as of their last sync.
+	durable map[dirEntry]*durableState
+
+	// gen is the generation of the file system. See own.
+	gen uint64
+	// clones maps entries shared with snapshots to their private copies.
+	clones map[dirEntry]dirEntry
+	// sharedMaps reports whether durable and clones are shared with a
+	// snapshot, requiring a copy before they are modified.
+	sharedMaps bool
 }
 
-func newVFS() vfs {
+// vUser describes a simulated user whose file permissions are enforced.
+type vUser struct {
+	uid int
+	gid int
+}
+
+func newVFS(c config) *vfs {
+	sep := string(filepath.Separator)
 	v := &vfs{
-		entries: newVDir(),
+		pwd:   sep,
+		umask: c.umask,
+		now:   c.now,
+		dev:   atomic.AddUint64(&devs, 1),
+		gen:   newGen(),
+
+		durable: make(map[dirEntry]*durableState),
+		clones:  make(map[dirEntry]dirEntry),
 	}
+	v.entries = newVDir(v.newMeta(0755))
 
-	sep := string(v.pathSeparator())
-	mkdir := func(p string) {
-		if err := v.Mkdir(p, 0700); err != nil {
+	mkdir := func(p string, perm fs.FileMode, owned bool) {
+		if err := v.Mkdir(p, 0); err != nil {
 			panic(err)
 		}
+		e, _ := v.get(p)
+		e.meta().perm = perm
+		if owned && c.user != nil {
+			e.meta().uid, e.meta().gid = c.user.uid, c.user.gid
+		}
 	}
 
 	v.temp = sep + "temp"
-	mkdir(v.temp)
+	mkdir(v.temp, 0777|fs.ModeSticky, false)
 
 	v.home = sep + "home"
-	mkdir(v.home)
+	mkdir(v.home, 0700, true)
 	v.usrCch = filepath.Join(v.home, ".cache")
-	mkdir(v.usrCch)
+	mkdir(v.usrCch, 0700, true)
 	v.usrCfg = filepath.Join(v.home, ".config")
-	mkdir(v.usrCfg)
+	mkdir(v.usrCfg, 0700, true)
 
 	v.pwd = v.home
+	v.user = c.user
+	for _, l := range c.limits {
+		l.dir = v.abs(l.dir)
+		v.limits = append(v.limits, l)
+	}
+	v.syncAll()
+
+	return v
+}
+
+func (v *vfs) Open(name string) (fs.File, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.openFile(name, os.O_RDONLY, 0)
+}
 
-	return *v
+func (v *vfs) Stat(name string) (fs.FileInfo, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	e, err := v.get(name)
+	if err != nil {
+		return nil, newPathError("stat", name, err)
+	}
+	return newFileInfo(filepath.Base(v.abs(name)), e, v.dev), nil
 }
 
-func (v vfs) Open(name string) (fs.File, error) {
-	got, err := v.get(name)
+func (v *vfs) openFile(name string, flag int, perm fs.FileMode) (*fsFile, error) {
+	loc, err := v.locate(name, true)
 	if err != nil {
 		return nil, newPathError("open", name, err)
 	}
-	_, base := filepath.Split(name)
-	return got.toFile(base)
+	got := loc.entry
+	if got == nil {
+		if flag&os.O_CREATE == 0 {
+			return nil, newPathError("open", name, fs.ErrNotExist)
+		}
+		if v.endsInDir(name) {
+			return nil, newPathError("open", name, errIsDir)
+		}
+		if err := v.access(loc.parent, permW); err != nil {
+			return nil, newPathError("open", name, err)
+		}
+		if err := v.reserveEntry(loc.parent); err != nil {
+			return nil, newPathError("open", name, err)
+		}
+		got = newVFile(v.newMeta(perm), nil)
+		if err := loc.parent.add(loc.base, got); err != nil {
+			return nil, newPathError("open", name, err)
+		}
+		v.addUsage(loc.parent, got)
+		loc.parent.modified(v.now())
+	} else if flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL {
+		return nil, newPathError("open", name, fs.ErrExist)
+	} else if err := v.access(got, openPerms(flag)); err != nil {
+		return nil, newPathError("open", name, err)
+	}
+	f, err := got.toFile(name, flag)
+	if err != nil {
+		return nil, newPathError("open", name, err)
+	}
+	f.fsys = v
+	f.boot = v.boot
+	if file, ok := got.(*vFile); ok && flag&os.O_TRUNC != 0 && flag&accessModes != os.O_RDONLY {
+		v.growUsage(file, -int64(file.size()))
+		file.truncate(0)
+		file.modified(v.now())
+	}
+	return f, nil
+}
+
+func (v *vfs) Mkdir(name string, perm fs.FileMode) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.mkdir(name, perm)
 }
 
-func (v vfs) Mkdir(name string, perm fs.FileMode) error {
-	parent, base := filepath.Split(name)
-	parDir, err := v.getDir(parent)
+func (v *vfs) mkdir(name string, perm fs.FileMode) error {
+	parDir, base, err := v.resolve(name)
 	if err != nil {
-		return newPathError("mkdir", parent, err)
+		return newPathError("mkdir", name, err)
+	}
+	if base == "" || parDir.has(base) {
+		return newPathError("mkdir", name, fs.ErrExist)
+	}
+	if err := v.access(parDir, permW); err != nil {
+		return newPathError("mkdir", name, err)
+	}
+	if err := v.reserveEntry(parDir); err != nil {
+		return newPathError("mkdir", name, err)
 	}
-	if err := parDir.add(base, newVDir()); err != nil {
+	dir := newVDir(v.newMeta(perm))
+	if err := parDir.add(base, dir); err != nil {
 		return newPathError("mkdir", name, err)
 	}
+	v.addUsage(parDir, dir)
+	parDir.modified(v.now())
 	return nil
 }
 
-func (v vfs) get(p string) (dirEntry, error) {
-	dir := v.entries
-	var got dirEntry = dir
-	var err error
-	for _, name := range v.splitPath(p) {
-		got, err = dir.get(name)
-		if err != nil {
-			return nil, err
-		}
-		dir, _ = got.(vDir)
+func (v *vfs) Chdir(dir string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	d, err := v.getDir(dir)
+	if err == nil {
+		err = v.access(d, permX)
+	}
+	if err != nil {
+		return newPathError("chdir", dir, err)
+	}
+	v.pwd = v.abs(dir)
+	return nil
+}
+
+// get returns the entry of a given path, following symbolic links.
+func (v *vfs) get(p string) (dirEntry, error) {
+	return v.getEntry(p, true)
+}
+
+// lget returns the entry of a given path. If the path denotes a symbolic
+// link, the link itself is returned.
+func (v *vfs) lget(p string) (dirEntry, error) {
+	return v.getEntry(p, false)
+}
+
+func (v *vfs) getEntry(p string, follow bool) (dirEntry, error) {
+	loc, err := v.locate(p, follow)
+	if err != nil {
+		return nil, err
+	}
+	if loc.entry == nil {
+		return nil, fs.ErrNotExist
 	}
-	return got, nil
+	return loc.entry, nil
 }
 
-func (v vfs) getDir(p string) (vDir, error) {
+func (v *vfs) getDir(p string) (*vDir, error) {
 	e, err := v.get(p)
 	if err != nil {
-		return vDir{}, err
+		return nil, err
 	}
-	dir, ok := e.(vDir)
+	dir, ok := e.(*vDir)
 	if !ok {
-		return vDir{}, errNotDir
+		return nil, errNotDir
 	}
 	return dir, nil
 }
 
-func (vfs) pathSeparator() uint8 {
+// resolve returns the parent directory and base name of a given path.
+//
+// Symbolic links are followed in all but the last path component. A path that
+// ends in a separator or "." but names a symbolic link results in errNotDir,
+// as the link itself is not a directory. The base name is empty if the path
+// does not denote a named directory entry, e.g. for the root directory.
+func (v *vfs) resolve(p string) (parent *vDir, base string, err error) {
+	loc, err := v.resolveLocation(p)
+	return loc.parent, loc.base, err
+}
+
+// resolveLocation resolves a given path like resolve does, but returns its
+// location.
+func (v *vfs) resolveLocation(p string) (location, error) {
+	loc, err := v.locate(p, false)
+	if err != nil {
+		return location{}, err
+	}
+	if loc.viaLink {
+		return location{}, errNotDir
+	}
+	if loc.base == "" {
+		loc.parent = v.entries
+	}
+	return loc, nil
+}
+
+// location describes a resolved path.
+type location struct {
+	// parent is the directory containing the entry.
+	parent *vDir
+	// base is the name of the entry within its parent directory. It is empty
+	// if the path does not end in a named entry, e.g. for the root directory.
+	base string
+	// entry is the resolved entry, or nil if the last path component is
+	// missing.
+	entry dirEntry
+	// dirs holds the directories the path resolved through, from the root
+	// directory to the parent directory, or to the entry if the path does not
+	// end in a named entry.
+	dirs []*vDir
+	// viaLink reports whether the last path component is a symbolic link that
+	// was only followed as the path must name a directory.
+	viaLink bool
+}
+
+// maxSymlinks limits the number of symbolic links followed per lookup.
+const maxSymlinks = 40
+
+// locate resolves a given path.
+//
+// Symbolic links are followed in all intermediate path components. A symbolic
+// link in the last path component is only followed if follow is true.
+// Missing intermediate directories result in fs.ErrNotExist, whereas a missing
+// last path component results in a location without an entry.
+//
+// A path ending in a separator or a "." component, e.g. "a/" or "a/.", must
+// name a directory: its last path component is followed if it is a symbolic
+// link, and errNotDir is returned if it exists but is not a directory.
+func (v *vfs) locate(p string, follow bool) (location, error) {
+	if p == "" {
+		return location{}, fs.ErrNotExist
+	}
+	if !filepath.IsAbs(p) {
+		p = v.pwd + string(v.pathSeparator()) + p
+	}
+	stack := []*vDir{v.root()}
+	names := v.splitPath(p)
+	mustDir := v.endsInDir(p)
+	viaLink := false
+	hops := 0
+	for len(names) > 0 {
+		name := names[0]
+		names = names[1:]
+		dir := stack[len(stack)-1]
+		if name == ".." {
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+		if err := v.access(dir, permX); err != nil {
+			return location{}, err
+		}
+		isLast := len(names) == 0
+		e := dir.tryGet(name)
+		if e == nil {
+			if isLast {
+				return location{parent: dir, base: name, dirs: stack, viaLink: viaLink}, nil
+			}
+			return location{}, fs.ErrNotExist
+		}
+		e = v.ownChild(dir, name, e)
+		if l, ok := e.(*vSymlink); ok && (!isLast || follow || mustDir) {
+			if hops++; hops > maxSymlinks {
+				return location{}, errLoop
+			}
+			if filepath.IsAbs(l.target) {
+				stack = stack[:1]
+			}
+			if isLast {
+				viaLink = viaLink || !follow
+				mustDir = mustDir || v.endsInDir(l.target)
+			}
+			names = append(v.splitPath(l.target), names...)
+			continue
+		}
+		if isLast {
+			if _, ok := e.(*vDir); mustDir && !ok {
+				return location{}, errNotDir
+			}
+			return location{parent: dir, base: name, entry: e, dirs: stack, viaLink: viaLink}, nil
+		}
+		d, ok := e.(*vDir)
+		if !ok {
+			return location{}, errNotDir
+		}
+		stack = append(stack, d)
+	}
+	return location{entry: stack[len(stack)-1], dirs: stack, viaLink: viaLink}, nil
+}
+
+// unlink removes a named entry from its parent directory.
+func (v *vfs) unlink(parent *vDir, base string) {
+	e := parent.tryGet(base)
+	if e == nil {
+		return
+	}
+	e = v.ownChild(parent, base, e)
+	parent.delete(base)
+	v.dropUsage()
+	now := v.now()
+	parent.modified(now)
+	e.meta().nlink--
+	e.meta().changed(now)
+}
+
+// unlinkAll removes a named entry and all its children.
+func (v *vfs) unlinkAll(parent *vDir, base string) {
+	e := parent.tryGet(base)
+	if e == nil {
+		return
+	}
+	if dir, ok := v.ownChild(parent, base, e).(*vDir); ok {
+		for name := range dir.dirEntries {
+			v.unlinkAll(dir, name)
+		}
+	}
+	v.unlink(parent, base)
+}
+
+// newMeta returns metadata for a new entry owned by the current user.
+func (v *vfs) newMeta(perm fs.FileMode) entryMeta {
+	now := v.now()
+	v.inos++
+	m := entryMeta{
+		perm:  perm & modeMask &^ v.umask,
+		ino:   v.inos,
+		nlink: 1,
+		atime: now,
+		mtime: now,
+		ctime: now,
+		gen:   v.gen,
+	}
+	if v.user != nil {
+		m.uid, m.gid = v.user.uid, v.user.gid
+	}
+	return m
+}
+
+// access checks whether the simulated user has the requested permissions on
+// an entry.
+//
+// Permissions are only enforced when a simulated non-root user is set.
+func (v *vfs) access(e dirEntry, want fs.FileMode) error {
+	if v.user == nil || v.user.uid == 0 {
+		return nil
+	}
+	m := e.meta()
+	perm := m.perm & fs.ModePerm
+	switch {
+	case m.uid == v.user.uid:
+		perm >>= 6
+	case m.gid == v.user.gid:
+		perm >>= 3
+	}
+	if perm&want != want {
+		return fs.ErrPermission
+	}
+	return nil
+}
+
+// isOwner reports whether the simulated user may change an entry's metadata.
+func (v *vfs) isOwner(e dirEntry) bool {
+	return v.user == nil || v.user.uid == 0 || v.user.uid == e.meta().uid
+}
+
+// openPerms returns the permissions required to open a file with a flag.
+func openPerms(flag int) fs.FileMode {
+	var perm fs.FileMode
+	switch flag & accessModes {
+	case os.O_RDONLY:
+		perm = permR
+	case os.O_WRONLY:
+		perm = permW
+	case os.O_RDWR:
+		perm = permR | permW
+	}
+	if flag&os.O_TRUNC != 0 {
+		perm |= permW
+	}
+	return perm
+}
+
+func (*vfs) pathSeparator() uint8 {
 	return filepath.Separator
 }
 
-// splitPath splits a given path into a slice of path components.
-func (v vfs) splitPath(p string) []string {
+// abs returns a clean, absolute representation of a given path.
+//
+// Relative paths are resolved against the current working directory.
+func (v *vfs) abs(p string) string {
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(v.pwd, p)
+	}
+	return filepath.Clean(p)
+}
+
+// endsInDir reports whether a given path ends in a separator or a "."
+// component, and thus must name a directory.
+func (v *vfs) endsInDir(p string) bool {
 	sep := string(v.pathSeparator())
-	p = filepath.Clean(p)
-	if p == sep {
-		return nil
-	} else if !strings.HasPrefix(p, sep) {
-		panic(errors.New("unexpected relative path"))
+	return strings.HasSuffix(p, sep) || p == "." || strings.HasSuffix(p, sep+".")
+}
+
+// splitPath splits a given path into a slice of path components, omitting
+// empty and "." components.
+func (v *vfs) splitPath(p string) []string {
+	parts := strings.Split(p, string(v.pathSeparator()))
+	names := parts[:0]
+	for _, n := range parts {
+		if n != "" && n != "." {
+			names = append(names, n)
+		}
 	}
-	return strings.Split(p, sep)[1:]
+	return names
 }
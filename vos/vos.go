@@ -7,11 +7,15 @@
 package vos
 
 import (
+	"time"
+
 	os "github.com/echocrow/osa"
 )
 
-func Patch() (vos, func()) {
-	o := New()
+// Patch creates a new vos instance and monkey-patches the OS abstraction with
+// it. It returns the instance and a restore function.
+func Patch(opts ...Option) (vos, func()) {
+	o := New(opts...)
 	restore := os.Patch(o)
 	return o, restore
 }
@@ -19,11 +23,58 @@ func Patch() (vos, func()) {
 type vos struct {
 	vosFS
 	vosIO
+	vosEnv
 }
 
-func New() vos {
+// New creates a new vos instance.
+//
+// Each instance has its own isolated file system, stdio, and environment. An
+// instance and the files opened from it are safe for concurrent use.
+func New(opts ...Option) vos {
+	c := config{
+		umask: 0022,
+		now:   time.Now,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
 	return vos{
-		vosFS: newFS(),
-		vosIO: newIO(),
+		vosFS:  newFS(c),
+		vosIO:  newIO(),
+		vosEnv: newEnv(c),
+	}
+}
+
+// An Option configures a vos instance created via New or Patch.
+type Option func(c *config)
+
+type config struct {
+	env   []string
+	umask os.FileMode
+	user  *vUser
+	now   func() time.Time
+
+	limits []limit
+}
+
+// WithUmask sets the file mode creation mask of the vos instance.
+//
+// The umask defaults to 0022.
+func WithUmask(mask os.FileMode) Option {
+	return func(c *config) {
+		c.umask = mask & os.ModePerm
+	}
+}
+
+// WithUser enforces file permissions for a simulated user.
+//
+// By default, no file permissions are enforced. With a user set, operations
+// fail with fs.ErrPermission when the user lacks the read, write, or execute
+// permissions Linux would require. New files and directories, as well as the
+// user's home directory, are owned by the user. A uid of 0 denotes root, who
+// bypasses all permission checks.
+func WithUser(uid, gid int) Option {
+	return func(c *config) {
+		c.user = &vUser{uid, gid}
 	}
 }
@@ -6,9 +6,11 @@ import (
 	"testing"
 
 	"github.com/echocrow/osa"
+	"github.com/echocrow/osa/testos"
 	"github.com/echocrow/osa/testosa"
 	"github.com/echocrow/osa/vos"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPatch(t *testing.T) {
@@ -45,3 +47,76 @@ func TestVos(t *testing.T) {
 
 	testosa.AssertOsa(t, v, mkTempDir, assertExit, getStdio)
 }
+
+func TestRelativePaths(t *testing.T) {
+	v := vos.New()
+
+	home, err := v.UserHomeDir()
+	require.NoError(t, err)
+	wd, err := v.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, home, wd)
+
+	testos.RequireWrite(t, v, "config.yaml", "some config")
+	testos.AssertFileData(t, v, testos.Join(home, "config.yaml"), "some config")
+
+	require.NoError(t, v.Chdir(".."))
+	testos.AssertFileData(t, v, testos.Join("home", "config.yaml"), "some config")
+}
+
+func TestTrailingSlash(t *testing.T) {
+	v := vos.New()
+	tmpDir := vos.MkTempDir(v)
+	dir := testos.Join(tmpDir, "dir")
+	file := testos.Join(tmpDir, "file")
+	testos.RequireMkdirAll(t, v, dir)
+	testos.RequireWrite(t, v, file, "some data")
+	require.NoError(t, v.Symlink(dir, testos.Join(tmpDir, "dirlink")))
+	require.NoError(t, v.Symlink(file, testos.Join(tmpDir, "filelink")))
+
+	for _, p := range []string{"dir/", "dir/.", "dirlink/", "dirlink/."} {
+		fi, err := v.Stat(tmpDir + "/" + p)
+		if assert.NoError(t, err, p) {
+			assert.True(t, fi.IsDir(), p)
+		}
+		fi, err = v.Lstat(tmpDir + "/" + p)
+		if assert.NoError(t, err, p) {
+			assert.True(t, fi.IsDir(), p)
+		}
+	}
+
+	for _, p := range []string{"file/", "file/.", "filelink/", "filelink/."} {
+		_, err := v.Stat(tmpDir + "/" + p)
+		if assert.Error(t, err, p) {
+			assert.Contains(t, err.Error(), "not a directory", p)
+		}
+		_, err = v.Open(tmpDir + "/" + p)
+		if assert.Error(t, err, p) {
+			assert.Contains(t, err.Error(), "not a directory", p)
+		}
+	}
+
+	// Modifying calls do not follow symbolic links in the last component.
+	for _, err := range []error{
+		v.Remove(tmpDir + "/dirlink/"),
+		v.Rename(tmpDir+"/dirlink/", tmpDir+"/moved"),
+		v.Remove(tmpDir + "/file/"),
+	} {
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "not a directory")
+		}
+	}
+	assert.Error(t, v.Rename(tmpDir+"/file", tmpDir+"/dirlink/"))
+	assert.Error(t, v.Symlink(dir, tmpDir+"/dirlink/"))
+	testos.AssertExistsIsDir(t, v, dir, true)
+	testos.AssertFileData(t, v, file, "some data")
+	target, err := v.Readlink(testos.Join(tmpDir, "dirlink"))
+	require.NoError(t, err)
+	assert.Equal(t, dir, target)
+
+	require.NoError(t, v.Mkdir(testos.Join(tmpDir, "new")+"/", 0700))
+	testos.AssertExistsIsDir(t, v, testos.Join(tmpDir, "new"), true)
+	require.NoError(t, v.Rename(testos.Join(tmpDir, "new")+"/", testos.Join(tmpDir, "renamed")+"/"))
+	require.NoError(t, v.Remove(testos.Join(tmpDir, "renamed")+"/"))
+	testos.AssertNotExists(t, v, testos.Join(tmpDir, "renamed"))
+}